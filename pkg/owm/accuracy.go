@@ -0,0 +1,245 @@
+package owm
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pendingRetention is how long a forecast waits for an observation after its
+// target time before it is discarded.
+const pendingRetention = 2 * time.Hour
+
+var (
+	metricForecastErrorMAEDesc = prometheus.NewDesc(
+		"weather_forecast_error_mae",
+		"Rolling mean absolute error of the forecast against observed conditions",
+		[]string{"location", "provider", "condition", "lead_time"},
+		nil,
+	)
+
+	metricForecastErrorBiasDesc = prometheus.NewDesc(
+		"weather_forecast_error_bias",
+		"Rolling mean error (forecast - observed) of the forecast against observed conditions",
+		[]string{"location", "provider", "condition", "lead_time"},
+		nil,
+	)
+
+	metricForecastErrorSamplesDesc = prometheus.NewDesc(
+		"weather_forecast_error_samples",
+		"Number of forecasts in the rolling error window",
+		[]string{"location", "provider", "condition", "lead_time"},
+		nil,
+	)
+)
+
+// scoredConditions are the conditions whose forecasts are scored.
+var scoredConditions = []string{"clouds", "dew_point", "humidity", "pressure", "temp", "wind_speed"}
+
+// leadTimes are the buckets forecast lead times are grouped into, by their
+// upper bound in hours.
+var leadTimes = []struct {
+	name  string
+	hours float64
+}{
+	{"0-6h", 6},
+	{"6-12h", 12},
+	{"12-24h", 24},
+	{"24-48h", 48},
+	{"48h+", math.Inf(1)},
+}
+
+func leadTime(hours float64) string {
+	for _, l := range leadTimes {
+		if hours <= l.hours {
+			return l.name
+		}
+	}
+
+	return leadTimes[len(leadTimes)-1].name
+}
+
+type sourceKey struct {
+	provider string
+	location string
+}
+
+type accuracyKey struct {
+	provider  string
+	location  string
+	condition string
+	leadTime  string
+}
+
+// forecastAccuracy scores forecasts against the conditions observed at their
+// target time.
+type forecastAccuracy struct {
+	mtx    sync.Mutex
+	window int
+
+	// pending holds the forecast values by provider and location, target
+	// hour, and condition and lead time.
+	pending map[sourceKey]map[int64]map[accuracyKey]float64
+	// scored is the latest target hour scored for each provider and
+	// location.  Forecasts for it and earlier hours are not recorded again.
+	scored map[sourceKey]int64
+	// errors holds the most recent forecast errors, oldest first.
+	errors map[accuracyKey][]float64
+}
+
+func newForecastAccuracy(window int) *forecastAccuracy {
+	return &forecastAccuracy{
+		window:  window,
+		pending: make(map[sourceKey]map[int64]map[accuracyKey]float64),
+		scored:  make(map[sourceKey]int64),
+		errors:  make(map[accuracyKey][]float64),
+	}
+}
+
+// record stores the forecast hours of a location from a provider made at now.
+// A later forecast for the same target hour and lead time replaces an earlier
+// one, until the target hour has been scored.
+func (a *forecastAccuracy) record(provider, location string, now time.Time, hours []Forecast) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	source := sourceKey{provider, location}
+
+	targets := a.pending[source]
+	if targets == nil {
+		targets = make(map[int64]map[accuracyKey]float64)
+		a.pending[source] = targets
+	}
+
+	for _, hour := range hours {
+		target := time.Unix(int64(hour.Dt), 0).Round(time.Hour)
+
+		lead := target.Sub(now).Hours()
+		if lead <= 0 {
+			continue
+		}
+
+		// Observations are scored against the nearest hour, which from half
+		// past is still ahead.
+		if scored, ok := a.scored[source]; ok && target.Unix() <= scored {
+			continue
+		}
+
+		values := targets[target.Unix()]
+		if values == nil {
+			values = make(map[accuracyKey]float64)
+			targets[target.Unix()] = values
+		}
+
+		for _, condition := range scoredConditions {
+			if value, ok := hour.Conditions[condition]; ok {
+				values[accuracyKey{provider, location, condition, leadTime(lead)}] = value
+			}
+		}
+	}
+
+	for target := range targets {
+		if now.Sub(time.Unix(target, 0)) > pendingRetention {
+			delete(targets, target)
+		}
+	}
+}
+
+// score compares the forecasts from a provider for the hour nearest t with the
+// conditions observed at t.  Each forecast is scored once.
+func (a *forecastAccuracy) score(provider, location string, t time.Time, observed map[string]float64) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	source := sourceKey{provider, location}
+	target := t.Round(time.Hour).Unix()
+
+	if scored, ok := a.scored[source]; ok && target <= scored {
+		return
+	}
+
+	values, ok := a.pending[source][target]
+	if !ok {
+		return
+	}
+	delete(a.pending[source], target)
+	a.scored[source] = target
+
+	for key, forecast := range values {
+		value, ok := observed[key.condition]
+		if !ok {
+			continue
+		}
+
+		errs := append(a.errors[key], forecast-value)
+		if len(errs) > a.window {
+			errs = errs[len(errs)-a.window:]
+		}
+		a.errors[key] = errs
+	}
+}
+
+// stats returns the mean absolute error, the mean error and the number of
+// errors in the window for key.
+func (a *forecastAccuracy) stats(key accuracyKey) (mae, bias float64, n int) {
+	errs := a.errors[key]
+	if len(errs) == 0 {
+		return 0, 0, 0
+	}
+
+	for _, e := range errs {
+		mae += math.Abs(e)
+		bias += e
+	}
+
+	n = len(errs)
+
+	return mae / float64(n), bias / float64(n), n
+}
+
+// scoreForecasts scores the forecasts of a location from a provider against
+// the conditions observed at t, preferring the location's local station when
+// it has a recent observation.
+func (o *OWM) scoreForecasts(provider string, location Location, t time.Time, conditions map[string]float64) {
+	if location.Station != "" {
+		o.mtx.Lock()
+		obs, ok := o.observed[location.Station]
+		o.mtx.Unlock()
+
+		if ok && time.Since(obs.Time) <= observationTTL {
+			t, conditions = obs.Time, obs.Conditions
+		}
+	}
+
+	o.accuracy.score(provider, location.Name, t, conditions)
+}
+
+// collectAccuracy sends the rolling forecast error statistics.
+func (o *OWM) collectAccuracy(ch chan<- prometheus.Metric) {
+	o.accuracy.mtx.Lock()
+	defer o.accuracy.mtx.Unlock()
+
+	for key := range o.accuracy.errors {
+		mae, bias, n := o.accuracy.stats(key)
+
+		values := map[*prometheus.Desc]float64{
+			metricForecastErrorMAEDesc:     mae,
+			metricForecastErrorBiasDesc:    bias,
+			metricForecastErrorSamplesDesc: float64(n),
+		}
+
+		for desc, value := range values {
+			ch <- prometheus.MustNewConstMetric(
+				desc,
+				prometheus.GaugeValue,
+				value,
+				key.location,
+				key.provider,
+				key.condition,
+				key.leadTime,
+			)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package owm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecastAccuracy(t *testing.T) {
+	a := newForecastAccuracy(2)
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	target := now.Add(3 * time.Hour)
+
+	// Two forecasts for the same target and lead time, the later one wins.
+	a.record(ProviderOpenWeatherMap, "home", now, []Forecast{{Dt: int(target.Unix()), Conditions: map[string]float64{"temp": 20}}})
+	a.record(ProviderOpenWeatherMap, "home", now.Add(10*time.Minute), []Forecast{{Dt: int(target.Unix()), Conditions: map[string]float64{"temp": 22}}})
+
+	// Observed ten minutes past the target hour.
+	a.score(ProviderOpenWeatherMap, "home", target.Add(10*time.Minute), map[string]float64{"temp": 19})
+	// A second observation in the same hour is not scored again.
+	a.score(ProviderOpenWeatherMap, "home", target.Add(20*time.Minute), map[string]float64{"temp": 30})
+
+	mae, bias, n := a.stats(accuracyKey{ProviderOpenWeatherMap, "home", "temp", "0-6h"})
+	require.Equal(t, 1, n)
+	require.Equal(t, 3.0, mae)
+	require.Equal(t, 3.0, bias)
+
+	next := target.Add(time.Hour)
+	a.record(ProviderOpenWeatherMap, "home", now, []Forecast{{Dt: int(next.Unix()), Conditions: map[string]float64{"temp": 15}}})
+	a.score(ProviderOpenWeatherMap, "home", next, map[string]float64{"temp": 18})
+
+	mae, bias, n = a.stats(accuracyKey{ProviderOpenWeatherMap, "home", "temp", "0-6h"})
+	require.Equal(t, 2, n)
+	require.Equal(t, 3.0, mae)
+	require.Equal(t, 0.0, bias)
+
+	require.Equal(t, "24-48h", leadTime(30))
+	require.Equal(t, "48h+", leadTime(60))
+}
+
+func TestEmitCorrected(t *testing.T) {
+	o := newTestOWM(Config{ForecastCorrection: true, CorrectionMinSamples: 1}, nil)
+	o.accuracy = newForecastAccuracy(10)
+
+	now := time.Now()
+	target := now.Add(2 * time.Hour).Round(time.Hour)
+
+	// The valley runs 3°C colder than forecast.
+	o.accuracy.record(ProviderOpenWeatherMap, "valley", now, []Forecast{{Dt: int(target.Unix()), Conditions: map[string]float64{"temp": 10, "humidity": 99}}})
+	o.accuracy.score(ProviderOpenWeatherMap, "valley", target, map[string]float64{"temp": 7, "humidity": 100})
+
+	ch := make(chan prometheus.Metric, 10)
+	o.emitCorrected(ch, ProviderOpenWeatherMap, Location{Name: "valley"}, now, []Forecast{{Dt: int(target.Unix()), Conditions: map[string]float64{"temp": 12, "humidity": 100}}})
+	close(ch)
+
+	corrected := map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		require.NoError(t, m.Write(pb))
+		corrected[pb.GetLabel()[0].GetValue()] = pb.GetGauge().GetValue()
+	}
+
+	require.Equal(t, 9.0, corrected["temp"])
+	require.Equal(t, 100.0, corrected["humidity"])
+}
+
+func TestForecastAccuracyInterleaved(t *testing.T) {
+	a := newForecastAccuracy(10)
+	now := time.Date(2022, 7, 1, 12, 40, 0, 0, time.UTC)
+	target := time.Date(2022, 7, 1, 13, 0, 0, 0, time.UTC)
+	hours := []Forecast{{Dt: int(target.Unix()), Conditions: map[string]float64{"temp": 20}}}
+
+	// Each scrape records the forecast and scores the observation, which
+	// from half past rounds to the forecast hour.
+	a.record(ProviderOpenWeatherMap, "home", now, hours)
+	a.score(ProviderOpenWeatherMap, "home", now, map[string]float64{"temp": 19})
+	a.record(ProviderOpenWeatherMap, "home", now.Add(time.Minute), hours)
+	a.score(ProviderOpenWeatherMap, "home", now.Add(time.Minute), map[string]float64{"temp": 19})
+
+	_, _, n := a.stats(accuracyKey{ProviderOpenWeatherMap, "home", "temp", "0-6h"})
+	require.Equal(t, 1, n)
+}
+
+func TestCorrectionInterleaved(t *testing.T) {
+	o := newTestOWM(Config{ForecastCorrection: true, CorrectionMinSamples: 2}, nil)
+	o.accuracy = newForecastAccuracy(10)
+
+	offsets := func() map[string]float64 {
+		ch := make(chan prometheus.Metric, 10)
+		o.collectCorrection(ch)
+		close(ch)
+
+		offsets := map[string]float64{}
+		for m := range ch {
+			pb := &dto.Metric{}
+			require.NoError(t, m.Write(pb))
+			offsets[pb.GetLabel()[0].GetValue()] = pb.GetGauge().GetValue()
+		}
+
+		return offsets
+	}
+
+	// Scrapes every five minutes from half past record the forecast for the
+	// next hour and score the observation against it.
+	scrape := func(hour time.Time, forecast, observed float64) {
+		for now := hour.Add(-20 * time.Minute); now.Before(hour.Add(-5 * time.Minute)); now = now.Add(5 * time.Minute) {
+			o.accuracy.record(ProviderOpenWeatherMap, "valley", now, []Forecast{{Dt: int(hour.Unix()), Conditions: map[string]float64{"temp": forecast}}})
+			o.accuracy.score(ProviderOpenWeatherMap, "valley", now, map[string]float64{"temp": observed})
+		}
+	}
+
+	hour := time.Date(2022, 7, 1, 13, 0, 0, 0, time.UTC)
+
+	// One forecast has been scored, short of the minimum samples.
+	scrape(hour, 20, 19)
+	require.Empty(t, offsets())
+
+	scrape(hour.Add(time.Hour), 20, 17)
+	require.Equal(t, map[string]float64{"temp": -2}, offsets())
+}
@@ -0,0 +1,290 @@
+package owm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	agroPolygonsURL = "https://api.agromonitoring.com/agro/1.0/polygons?appid=%s"
+	agroPolygonURL  = "https://api.agromonitoring.com/agro/1.0/polygons/%s?appid=%s"
+	agroSoilURL     = "https://api.agromonitoring.com/agro/1.0/soil?polyid=%s&appid=%s"
+
+	kelvin = 273.15
+)
+
+var (
+	metricSoilTemperatureDesc = prometheus.NewDesc(
+		"soil_temperature",
+		"Soil temperature (°C)",
+		[]string{"polygon", "depth"},
+		nil,
+	)
+
+	metricSoilMoistureDesc = prometheus.NewDesc(
+		"soil_moisture",
+		"Soil moisture (m³/m³)",
+		[]string{"polygon"},
+		nil,
+	)
+)
+
+type agroPolygon struct {
+	ID      string          `json:"id,omitempty"`
+	Name    string          `json:"name"`
+	GeoJSON json.RawMessage `json:"geo_json,omitempty"`
+}
+
+type agroSoil struct {
+	Dt       int     `json:"dt"`
+	T0       float64 `json:"t0"`
+	T10      float64 `json:"t10"`
+	Moisture float64 `json:"moisture"`
+}
+
+// collectSoil sends the soil conditions for the configured polygons,
+// registering them with the Agro API as needed.
+func (o *OWM) collectSoil(ctx context.Context, ch chan<- prometheus.Metric) {
+	ctx, span := o.tracer.Start(ctx, "collectSoil")
+	defer span.End()
+
+	for _, polygon := range o.cfg.Polygons {
+		id, err := o.polygonID(ctx, polygon)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			_ = level.Error(o.logger).Log("msg", "failed to register polygon", "polygon", polygon.Name, "err", err)
+			continue
+		}
+
+		var soil agroSoil
+		if err = o.getJSON(ctx, fmt.Sprintf(agroSoilURL, id, o.agroAPIKey()), &soil); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			_ = level.Error(o.logger).Log("msg", "failed to get soil data", "polygon", polygon.Name, "err", err)
+			continue
+		}
+
+		if soil.Dt == 0 {
+			continue
+		}
+
+		temperatures := map[string]float64{
+			"0cm":  soil.T0 - kelvin,
+			"10cm": soil.T10 - kelvin,
+		}
+
+		for depth, value := range temperatures {
+			ch <- prometheus.MustNewConstMetric(
+				metricSoilTemperatureDesc,
+				prometheus.GaugeValue,
+				value,
+				polygon.Name,
+				depth,
+			)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			metricSoilMoistureDesc,
+			prometheus.GaugeValue,
+			soil.Moisture,
+			polygon.Name,
+		)
+	}
+}
+
+// polygonID returns the Agro API ID of polygon, reusing a polygon already
+// registered under the same name and geometry or registering a new one.  A
+// polygon registered with a different geometry is replaced.
+func (o *OWM) polygonID(ctx context.Context, polygon Polygon) (string, error) {
+	// The Agro API is called without the lock held, so that a slow response
+	// does not hold up collection.
+	o.mtx.Lock()
+	id, ok := o.polygonIDs[polygon.Name]
+	existing, registered := o.agroPolygons[polygon.Name]
+	listed := o.agroPolygons != nil
+	o.mtx.Unlock()
+
+	if ok {
+		return id, nil
+	}
+
+	if !listed {
+		var polygons []agroPolygon
+		if err := o.getJSON(ctx, fmt.Sprintf(agroPolygonsURL, o.agroAPIKey()), &polygons); err != nil {
+			return "", err
+		}
+
+		byName := make(map[string]agroPolygon, len(polygons))
+		for _, p := range polygons {
+			byName[p.Name] = p
+		}
+
+		o.mtx.Lock()
+		if o.agroPolygons == nil {
+			o.agroPolygons = byName
+		}
+		existing, registered = o.agroPolygons[polygon.Name]
+		o.mtx.Unlock()
+	}
+
+	geoJSON, err := polygon.geoJSON()
+	if err != nil {
+		return "", err
+	}
+
+	if registered {
+		if sameGeometry(existing.GeoJSON, geoJSON) {
+			o.setPolygonID(polygon.Name, existing.ID)
+			return existing.ID, nil
+		}
+
+		if err = o.deletePolygon(ctx, existing.ID); err != nil {
+			return "", fmt.Errorf("failed to replace changed polygon: %w", err)
+		}
+
+		_ = level.Info(o.logger).Log("msg", "removed changed polygon", "polygon", polygon.Name, "id", existing.ID)
+
+		o.mtx.Lock()
+		delete(o.agroPolygons, polygon.Name)
+		o.mtx.Unlock()
+	}
+
+	created, err := o.createPolygon(ctx, agroPolygon{Name: polygon.Name, GeoJSON: geoJSON})
+	if err != nil {
+		return "", err
+	}
+
+	_ = level.Info(o.logger).Log("msg", "registered polygon", "polygon", polygon.Name, "id", created.ID)
+	o.setPolygonID(polygon.Name, created.ID)
+
+	return created.ID, nil
+}
+
+func (o *OWM) setPolygonID(name, id string) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	if o.polygonIDs == nil {
+		o.polygonIDs = make(map[string]string)
+	}
+	o.polygonIDs[name] = id
+}
+
+// sameGeometry returns whether two GeoJSON Features have the same geometry.
+func sameGeometry(a, b json.RawMessage) bool {
+	var fa, fb struct {
+		Geometry interface{} `json:"geometry"`
+	}
+
+	if json.Unmarshal(a, &fa) != nil || json.Unmarshal(b, &fb) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(fa.Geometry, fb.Geometry)
+}
+
+func (o *OWM) deletePolygon(ctx context.Context, id string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf(agroPolygonURL, id, o.agroAPIKey()), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (o *OWM) createPolygon(ctx context.Context, polygon agroPolygon) (*agroPolygon, error) {
+	resp, err := o.postJSON(ctx, fmt.Sprintf(agroPolygonsURL, o.agroAPIKey()), polygon)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	created := &agroPolygon{}
+	if err = json.NewDecoder(resp.Body).Decode(created); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (o *OWM) agroAPIKey() string {
+	if o.cfg.AgroAPIKey != "" {
+		return o.cfg.AgroAPIKey
+	}
+
+	return o.cfg.APIKey
+}
+
+// geoJSON returns the polygon as a GeoJSON Feature, built from either the
+// configured GeoJSON or the coordinate list.
+func (p Polygon) geoJSON() (json.RawMessage, error) {
+	if p.GeoJSON != "" {
+		var doc struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(p.GeoJSON), &doc); err != nil {
+			return nil, fmt.Errorf("invalid geojson for polygon %s: %w", p.Name, err)
+		}
+
+		switch doc.Type {
+		case "Feature":
+			return json.RawMessage(p.GeoJSON), nil
+		case "Polygon":
+			return json.Marshal(map[string]interface{}{
+				"type":       "Feature",
+				"properties": map[string]interface{}{},
+				"geometry":   json.RawMessage(p.GeoJSON),
+			})
+		default:
+			return nil, fmt.Errorf("unsupported geojson type %q for polygon %s", doc.Type, p.Name)
+		}
+	}
+
+	if len(p.Coordinates) < 3 {
+		return nil, fmt.Errorf("polygon %s needs at least 3 coordinates", p.Name)
+	}
+
+	ring := make([][]float64, 0, len(p.Coordinates)+1)
+	for _, c := range p.Coordinates {
+		if len(c) != 2 {
+			return nil, fmt.Errorf("polygon %s coordinates must be [longitude, latitude] pairs", p.Name)
+		}
+		ring = append(ring, c)
+	}
+
+	// GeoJSON rings are closed, ending on their first position.
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		ring = append(ring, first)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"type":       "Feature",
+		"properties": map[string]interface{}{},
+		"geometry": map[string]interface{}{
+			"type":        "Polygon",
+			"coordinates": [][][]float64{ring},
+		},
+	})
+}
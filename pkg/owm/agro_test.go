@@ -0,0 +1,72 @@
+package owm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolygonID(t *testing.T) {
+	field := Polygon{Name: "field", Coordinates: [][]float64{{-0.1, 51.5}, {-0.2, 51.5}, {-0.2, 51.6}}}
+
+	geoJSON, err := field.geoJSON()
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		existing string
+		id       string
+		requests []string
+	}{
+		"unchanged": {
+			existing: `[{"id":"old","name":"field","geo_json":` + string(geoJSON) + `}]`,
+			id:       "old",
+			requests: []string{"GET /agro/1.0/polygons"},
+		},
+		"changed": {
+			existing: `[{"id":"old","name":"field","geo_json":{"type":"Feature","properties":{},
+				"geometry":{"type":"Polygon","coordinates":[[[-0.1,51.5],[-0.3,51.5],[-0.3,51.7],[-0.1,51.5]]]}}}]`,
+			id: "new",
+			requests: []string{
+				"GET /agro/1.0/polygons",
+				"DELETE /agro/1.0/polygons/old",
+				"POST /agro/1.0/polygons",
+			},
+		},
+		"missing": {
+			existing: `[]`,
+			id:       "new",
+			requests: []string{"GET /agro/1.0/polygons", "POST /agro/1.0/polygons"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var requests []string
+			o := newTestOWM(Config{Polygons: []Polygon{field}}, func(req *http.Request) (*http.Response, error) {
+				requests = append(requests, req.Method+" "+req.URL.Path)
+
+				switch req.Method {
+				case http.MethodGet:
+					return jsonResponse(tc.existing), nil
+				case http.MethodDelete:
+					return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader(""))}, nil
+				default:
+					return jsonResponse(`{"id":"new","name":"field"}`), nil
+				}
+			})
+
+			// The ID is only looked up once.
+			for i := 0; i < 2; i++ {
+				id, err := o.polygonID(context.Background(), field)
+				require.NoError(t, err)
+				require.Equal(t, tc.id, id)
+			}
+
+			require.Equal(t, tc.requests, requests)
+		})
+	}
+}
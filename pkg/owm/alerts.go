@@ -0,0 +1,51 @@
+package owm
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// alertUnknown is the CAP value for a severity, urgency or certainty that is
+// not known.
+const alertUnknown = "Unknown"
+
+var metricWeatherAlertDesc = prometheus.NewDesc(
+	"weather_alert",
+	"Weather alert in effect, with the unix time it expires",
+	[]string{"location", "provider", "event", "severity", "urgency", "certainty"},
+	nil,
+)
+
+// emitAlerts sends the unexpired alerts from a provider for a location.
+// Alerts sharing the same labels, eg: an alert and its update, are reported
+// once with the latest expiry.
+func (o *OWM) emitAlerts(ch chan<- prometheus.Metric, provider string, location Location, alerts []Alert) {
+	now := time.Now().Unix()
+
+	expires := make(map[Alert]int)
+	for _, a := range alerts {
+		if a.Expires > 0 && int64(a.Expires) < now {
+			continue
+		}
+
+		key := Alert{Event: a.Event, Severity: a.Severity, Urgency: a.Urgency, Certainty: a.Certainty}
+		if e, ok := expires[key]; !ok || a.Expires > e {
+			expires[key] = a.Expires
+		}
+	}
+
+	for a, expiry := range expires {
+		ch <- prometheus.MustNewConstMetric(
+			metricWeatherAlertDesc,
+			prometheus.GaugeValue,
+			float64(expiry),
+			location.Name,
+			provider,
+			a.Event,
+			a.Severity,
+			a.Urgency,
+			a.Certainty,
+		)
+	}
+}
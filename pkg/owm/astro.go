@@ -0,0 +1,98 @@
+package owm
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	deg2rad = math.Pi / 180
+	rad2deg = 180 / math.Pi
+)
+
+// julianDays returns the number of days since the J2000.0 epoch.
+func julianDays(t time.Time) float64 {
+	return float64(t.Unix())/86400 + 2440587.5 - 2451545.0
+}
+
+// sunEquatorial returns the sun's right ascension and declination in degrees
+// at t, using the low precision formulae of the Astronomical Almanac.
+func sunEquatorial(t time.Time) (ra, decl float64) {
+	n := julianDays(t)
+
+	l := math.Mod(280.460+0.9856474*n, 360)
+	g := math.Mod(357.528+0.9856003*n, 360) * deg2rad
+	lambda := (l + 1.915*math.Sin(g) + 0.020*math.Sin(2*g)) * deg2rad
+	epsilon := (23.439 - 0.0000004*n) * deg2rad
+
+	ra = math.Atan2(math.Cos(epsilon)*math.Sin(lambda), math.Cos(lambda)) * rad2deg
+	decl = math.Asin(math.Sin(epsilon)*math.Sin(lambda)) * rad2deg
+
+	return ra, decl
+}
+
+// sunPosition returns the sun's elevation above the horizon and its azimuth
+// clockwise from north, in degrees, at t for the given coordinates.
+func sunPosition(t time.Time, latitude, longitude float64) (elevation, azimuth float64) {
+	ra, decl := sunEquatorial(t)
+
+	gmst := math.Mod(18.697374558+24.06570982441908*julianDays(t), 24)
+	ha := (gmst*15 + longitude - ra) * deg2rad
+
+	lat := latitude * deg2rad
+	dec := decl * deg2rad
+
+	elevation = math.Asin(math.Sin(lat)*math.Sin(dec)+math.Cos(lat)*math.Cos(dec)*math.Cos(ha)) * rad2deg
+	azimuth = math.Atan2(math.Sin(ha), math.Cos(ha)*math.Sin(lat)-math.Tan(dec)*math.Cos(lat))*rad2deg + 180
+
+	return elevation, azimuth
+}
+
+// Sun elevations in degrees defining sunrise and sunset, allowing for
+// refraction and the radius of the sun, and the ends of twilight.
+const (
+	sunriseElevation     = -0.833
+	civilTwilight        = -6.0
+	nauticalTwilight     = -12.0
+	astronomicalTwilight = -18.0
+)
+
+// equationOfTime returns how far apparent solar time is ahead of mean solar
+// time at t.
+func equationOfTime(t time.Time) time.Duration {
+	ra, _ := sunEquatorial(t)
+
+	l := math.Mod(280.460+0.9856474*julianDays(t), 360)
+	e := math.Mod(l-ra+540, 360) - 180
+
+	// The earth turns a degree every four minutes.
+	return time.Duration(e * 4 * float64(time.Minute))
+}
+
+// solarNoon returns the time the sun crosses the meridian at the longitude on
+// the UTC date of day.
+func solarNoon(day time.Time, longitude float64) time.Time {
+	y, m, d := day.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, time.UTC).Add(-time.Duration(longitude / 15 * float64(time.Hour)))
+
+	return noon.Add(-equationOfTime(noon))
+}
+
+// sunCrossing returns the times around noon when the sun is at the elevation
+// in degrees, rising and setting, or false when it stays above or below it all
+// day.
+func sunCrossing(noon time.Time, latitude, elevation float64) (time.Time, time.Time, bool) {
+	_, decl := sunEquatorial(noon)
+
+	lat := latitude * deg2rad
+	dec := decl * deg2rad
+
+	cosH := (math.Sin(elevation*deg2rad) - math.Sin(lat)*math.Sin(dec)) / (math.Cos(lat) * math.Cos(dec))
+	if cosH < -1 || cosH > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	h := time.Duration(math.Acos(cosH) * rad2deg / 15 * float64(time.Hour))
+
+	return noon.Add(-h), noon.Add(h), true
+}
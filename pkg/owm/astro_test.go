@@ -0,0 +1,89 @@
+package owm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSunPosition(t *testing.T) {
+	cases := []struct {
+		time                time.Time
+		latitude, longitude float64
+		elevation, azimuth  float64
+	}{
+		// Solstice noon on the equator, sun at the tropic of cancer.
+		{time.Date(2020, 6, 21, 12, 0, 0, 0, time.UTC), 0, 0, 66.56, 1.1},
+		// Boulder, CO reference values from the NOAA solar calculator.
+		{time.Date(2020, 6, 21, 19, 0, 0, 0, time.UTC), 40, -105, 73.4, 178.4},
+		{time.Date(2020, 6, 21, 14, 0, 0, 0, time.UTC), 40, -105, 25.6, 80.0},
+	}
+
+	for _, tc := range cases {
+		elevation, azimuth := sunPosition(tc.time, tc.latitude, tc.longitude)
+		require.InDelta(t, tc.elevation, elevation, 0.2)
+		require.InDelta(t, tc.azimuth, azimuth, 0.5)
+	}
+}
+
+func TestEstimateIrradiance(t *testing.T) {
+	noon := time.Date(2020, 6, 21, 19, 0, 0, 0, time.UTC)
+
+	clear := estimateIrradiance(noon, 40, -105, 0)
+	require.InDelta(t, 990, clear.GHI, 10)
+	require.InDelta(t, clear.GHI, clear.DHI+clear.DNI*0.9588, 1)
+
+	overcast := estimateIrradiance(noon, 40, -105, 100)
+	require.Less(t, overcast.GHI, clear.GHI/3)
+
+	night := estimateIrradiance(time.Date(2020, 6, 21, 6, 0, 0, 0, time.UTC), 40, -105, 0)
+	require.Equal(t, irradiance{}, night)
+
+	pv := &PV{KWp: 5, Tilt: 30, Azimuth: 180, Losses: 14}
+	require.InDelta(t, 4300, pv.power(planeOfArray(noon, 40, -105, clear, pv.Tilt, pv.Azimuth)), 100)
+}
+
+func TestSunEvents(t *testing.T) {
+	// London on the winter solstice, from HM Nautical Almanac Office tables.
+	events := sunEvents(time.Date(2020, 12, 21, 0, 0, 0, 0, time.UTC), 51.5, 0)
+
+	expected := map[string]time.Time{
+		"astronomical_dawn": time.Date(2020, 12, 21, 5, 59, 0, 0, time.UTC),
+		"nautical_dawn":     time.Date(2020, 12, 21, 6, 40, 0, 0, time.UTC),
+		"civil_dawn":        time.Date(2020, 12, 21, 7, 23, 0, 0, time.UTC),
+		"sunrise":           time.Date(2020, 12, 21, 8, 4, 0, 0, time.UTC),
+		"solar_noon":        time.Date(2020, 12, 21, 11, 58, 0, 0, time.UTC),
+		"sunset":            time.Date(2020, 12, 21, 15, 53, 0, 0, time.UTC),
+		"civil_dusk":        time.Date(2020, 12, 21, 16, 33, 0, 0, time.UTC),
+		"nautical_dusk":     time.Date(2020, 12, 21, 17, 16, 0, 0, time.UTC),
+		"astronomical_dusk": time.Date(2020, 12, 21, 17, 57, 0, 0, time.UTC),
+	}
+
+	require.Len(t, events, len(expected))
+	for event, tm := range expected {
+		require.WithinDuration(t, tm, events[event], 2*time.Minute, event)
+	}
+
+	// Tromsø has no sunset in midsummer, nor astronomical twilight.
+	events = sunEvents(time.Date(2020, 6, 21, 0, 0, 0, 0, time.UTC), 69.65, 18.96)
+	require.NotContains(t, events, "sunrise")
+	require.NotContains(t, events, "astronomical_dawn")
+	require.Contains(t, events, "solar_noon")
+}
+
+func TestNextSunEvents(t *testing.T) {
+	// Boulder, CO in the evening, from the NOAA solar calculator.
+	now := time.Date(2020, 6, 21, 22, 0, 0, 0, time.UTC)
+	next := nextSunEvents(now, 40, -105)
+
+	require.WithinDuration(t, time.Date(2020, 6, 22, 2, 32, 0, 0, time.UTC), next["sunset"], 2*time.Minute)
+	require.WithinDuration(t, time.Date(2020, 6, 22, 11, 32, 0, 0, time.UTC), next["sunrise"], 2*time.Minute)
+
+	// The sun next rises and sets at Tromsø after the polar night, in
+	// January.
+	now = time.Date(2020, 12, 1, 12, 0, 0, 0, time.UTC)
+	next = nextSunEvents(now, 69.65, 18.96)
+	require.Equal(t, time.January, next["sunrise"].Month())
+	require.True(t, next["sunset"].After(next["sunrise"]))
+}
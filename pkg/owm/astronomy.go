@@ -0,0 +1,164 @@
+package owm
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxSunEventDays is how far ahead the next sunrise or sunset is looked for,
+// which may be months away within the polar circles.
+const maxSunEventDays = 366
+
+var (
+	metricSunElevationDesc = prometheus.NewDesc(
+		"sun_elevation_degrees",
+		"Elevation of the sun above the horizon",
+		[]string{"location"},
+		nil,
+	)
+
+	metricSunAzimuthDesc = prometheus.NewDesc(
+		"sun_azimuth_degrees",
+		"Azimuth of the sun clockwise from north",
+		[]string{"location"},
+		nil,
+	)
+
+	metricSunEventDesc = prometheus.NewDesc(
+		"sun_event_timestamp_seconds",
+		"Time of today's sun events: (astronomical_dawn|nautical_dawn|civil_dawn|sunrise|solar_noon|sunset|civil_dusk|nautical_dusk|astronomical_dusk)",
+		[]string{"location", "event"},
+		nil,
+	)
+
+	metricSunDayLengthDesc = prometheus.NewDesc(
+		"sun_day_length_seconds",
+		"Time between today's sunrise and sunset",
+		[]string{"location"},
+		nil,
+	)
+
+	metricSunNextEventDesc = prometheus.NewDesc(
+		"sun_next_event_seconds",
+		"Time until the next sun event: (sunrise|sunset)",
+		[]string{"location", "event"},
+		nil,
+	)
+)
+
+// twilights are the elevations of the sun at the start of dawn and the end of
+// dusk.
+var twilights = map[string]float64{
+	"astronomical": astronomicalTwilight,
+	"nautical":     nauticalTwilight,
+	"civil":        civilTwilight,
+}
+
+// collectAstronomy sends the position of the sun, the times of its events and
+// the illumination of the moon at a location, calculated locally.  Today is
+// the day at the location by mean solar time, so no time zone is needed.
+func (o *OWM) collectAstronomy(ch chan<- prometheus.Metric, location Location, now time.Time) {
+	elevation, azimuth := sunPosition(now, location.Latitude, location.Longitude)
+
+	ch <- prometheus.MustNewConstMetric(metricSunElevationDesc, prometheus.GaugeValue, elevation, location.Name)
+	ch <- prometheus.MustNewConstMetric(metricSunAzimuthDesc, prometheus.GaugeValue, azimuth, location.Name)
+
+	events := sunEvents(localSolarDay(now, location.Longitude), location.Latitude, location.Longitude)
+	for event, t := range events {
+		ch <- prometheus.MustNewConstMetric(
+			metricSunEventDesc,
+			prometheus.GaugeValue,
+			float64(t.Unix()),
+			location.Name,
+			event,
+		)
+	}
+
+	if sunrise, ok := events["sunrise"]; ok {
+		ch <- prometheus.MustNewConstMetric(
+			metricSunDayLengthDesc,
+			prometheus.GaugeValue,
+			events["sunset"].Sub(sunrise).Seconds(),
+			location.Name,
+		)
+	} else {
+		// Polar day or night.
+		day := 0.0
+		if elevation, _ := sunPosition(events["solar_noon"], location.Latitude, location.Longitude); elevation > sunriseElevation {
+			day = (24 * time.Hour).Seconds()
+		}
+
+		ch <- prometheus.MustNewConstMetric(metricSunDayLengthDesc, prometheus.GaugeValue, day, location.Name)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		metricMoonIlluminationDesc,
+		prometheus.GaugeValue,
+		moonIllumination(now),
+		location.Name,
+	)
+
+	for event, t := range nextSunEvents(now, location.Latitude, location.Longitude) {
+		ch <- prometheus.MustNewConstMetric(
+			metricSunNextEventDesc,
+			prometheus.GaugeValue,
+			t.Sub(now).Seconds(),
+			location.Name,
+			event,
+		)
+	}
+}
+
+// localSolarDay returns the UTC date that is the date at the longitude by mean
+// solar time at t.
+func localSolarDay(t time.Time, longitude float64) time.Time {
+	local := t.UTC().Add(time.Duration(longitude / 15 * float64(time.Hour)))
+	y, m, d := local.Date()
+
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// sunEvents returns the times of the sun events of a day, leaving out those
+// that do not happen, eg: sunrise during polar night.
+func sunEvents(day time.Time, latitude, longitude float64) map[string]time.Time {
+	noon := solarNoon(day, longitude)
+
+	events := map[string]time.Time{"solar_noon": noon}
+
+	if rise, set, ok := sunCrossing(noon, latitude, sunriseElevation); ok {
+		events["sunrise"] = rise
+		events["sunset"] = set
+	}
+
+	for name, elevation := range twilights {
+		if dawn, dusk, ok := sunCrossing(noon, latitude, elevation); ok {
+			events[name+"_dawn"] = dawn
+			events[name+"_dusk"] = dusk
+		}
+	}
+
+	return events
+}
+
+// nextSunEvents returns the times of the next sunrise and sunset after now.
+func nextSunEvents(now time.Time, latitude, longitude float64) map[string]time.Time {
+	next := make(map[string]time.Time, 2)
+
+	day := localSolarDay(now, longitude)
+	for i := 0; i < maxSunEventDays && len(next) < 2; i++ {
+		rise, set, ok := sunCrossing(solarNoon(day.AddDate(0, 0, i), longitude), latitude, sunriseElevation)
+		if !ok {
+			continue
+		}
+
+		if _, found := next["sunrise"]; !found && rise.After(now) {
+			next["sunrise"] = rise
+		}
+		if _, found := next["sunset"]; !found && set.After(now) {
+			next["sunset"] = set
+		}
+	}
+
+	return next
+}
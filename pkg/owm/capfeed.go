@@ -0,0 +1,415 @@
+package owm
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	// maxFeedSize limits the size of feeds and CAP documents read.
+	maxFeedSize = 10 << 20
+
+	earthRadiusKm = 6371.0
+)
+
+// capAlert is a CAP 1.2 alert message.
+type capAlert struct {
+	Identifier string    `xml:"identifier"`
+	Status     string    `xml:"status"`
+	MsgType    string    `xml:"msgType"`
+	Info       []capInfo `xml:"info"`
+}
+
+type capInfo struct {
+	Language  string    `xml:"language"`
+	Event     string    `xml:"event"`
+	Urgency   string    `xml:"urgency"`
+	Severity  string    `xml:"severity"`
+	Certainty string    `xml:"certainty"`
+	Onset     string    `xml:"onset"`
+	Expires   string    `xml:"expires"`
+	Area      []capArea `xml:"area"`
+}
+
+type capArea struct {
+	AreaDesc string   `xml:"areaDesc"`
+	Polygon  []string `xml:"polygon"`
+	Circle   []string `xml:"circle"`
+	Geocode  []struct {
+		ValueName string `xml:"valueName"`
+		Value     string `xml:"value"`
+	} `xml:"geocode"`
+}
+
+// atomFeed is an Atom feed whose entries either embed a CAP alert or link to
+// one.
+type atomFeed struct {
+	Entries []struct {
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+		Content struct {
+			Alert *capAlert `xml:"alert"`
+		} `xml:"content"`
+	} `xml:"entry"`
+}
+
+// rssFeed is an RSS feed whose items link to CAP alerts.
+type rssFeed struct {
+	Items []struct {
+		Link string `xml:"link"`
+	} `xml:"channel>item"`
+}
+
+// collectAlertFeeds sends the alerts from the configured CAP feeds for the
+// locations they cover, labelled with the name of the feed as the provider.
+func (o *OWM) collectAlertFeeds(ctx context.Context, ch chan<- prometheus.Metric) {
+	ctx, span := o.tracer.Start(ctx, "collectAlertFeeds")
+	defer span.End()
+
+	for _, feed := range o.cfg.AlertFeeds {
+		alerts, err := o.readAlertFeed(ctx, feed)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			_ = level.Error(o.logger).Log("msg", "alert feed failed", "feed", feed.Name, "err", err)
+			continue
+		}
+
+		for _, location := range o.cfg.Locations {
+			if !feed.covers(location) {
+				continue
+			}
+
+			var matched []Alert
+			for _, a := range alerts {
+				matched = append(matched, a.matching(location, feed.Language)...)
+			}
+
+			if len(matched) > 0 {
+				o.emitAlerts(ch, feed.Name, location, matched)
+			}
+		}
+	}
+}
+
+// readAlertFeed reads the actual alerts from a feed, which is either a CAP
+// document, or an Atom or RSS feed of them.  Linked CAP documents are cached
+// while they remain in the feed, as they do not change.
+func (o *OWM) readAlertFeed(ctx context.Context, feed AlertFeed) ([]*capAlert, error) {
+	body, err := o.getBody(ctx, feed.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []*capAlert
+	var links []string
+
+	switch root {
+	case "alert":
+		var a capAlert
+		if err := xml.Unmarshal(body, &a); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, &a)
+
+	case "feed":
+		var f atomFeed
+		if err := xml.Unmarshal(body, &f); err != nil {
+			return nil, err
+		}
+
+		for _, e := range f.Entries {
+			if e.Content.Alert != nil {
+				alerts = append(alerts, e.Content.Alert)
+				continue
+			}
+
+			// Prefer a link typed as CAP over the entry's alternate link.
+			var link string
+			for _, l := range e.Links {
+				if strings.Contains(l.Type, "cap") {
+					link = l.Href
+					break
+				}
+				if link == "" && (l.Rel == "" || l.Rel == "alternate") {
+					link = l.Href
+				}
+			}
+
+			if link != "" {
+				links = append(links, link)
+			}
+		}
+
+	case "rss":
+		var f rssFeed
+		if err := xml.Unmarshal(body, &f); err != nil {
+			return nil, err
+		}
+
+		for _, i := range f.Items {
+			if link := strings.TrimSpace(i.Link); link != "" {
+				links = append(links, link)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown feed format %q", root)
+	}
+
+	o.mtx.Lock()
+	cached := o.capAlerts[feed.Name]
+	o.mtx.Unlock()
+
+	linked := make(map[string]*capAlert, len(links))
+	for _, link := range links {
+		a, ok := cached[link]
+		if !ok {
+			a, err = o.readCAP(ctx, link)
+			if err != nil {
+				_ = level.Warn(o.logger).Log("msg", "failed to read cap alert", "feed", feed.Name, "url", link, "err", err)
+				continue
+			}
+		}
+
+		linked[link] = a
+		alerts = append(alerts, a)
+	}
+
+	o.mtx.Lock()
+	if o.capAlerts == nil {
+		o.capAlerts = make(map[string]map[string]*capAlert)
+	}
+	o.capAlerts[feed.Name] = linked
+	o.mtx.Unlock()
+
+	actual := alerts[:0]
+	for _, a := range alerts {
+		if a.Status == "Actual" && a.MsgType != "Cancel" {
+			actual = append(actual, a)
+		}
+	}
+
+	return actual, nil
+}
+
+func (o *OWM) readCAP(ctx context.Context, url string) (*capAlert, error) {
+	body, err := o.getBody(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var a capAlert
+	if err := xml.Unmarshal(body, &a); err != nil {
+		return nil, err
+	}
+
+	if a.Identifier == "" {
+		return nil, fmt.Errorf("not a cap alert")
+	}
+
+	return &a, nil
+}
+
+// getBody reads the document at url.
+func (o *OWM) getBody(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", o.cfg.UserAgent)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+}
+
+// rootElement returns the local name of the root element of an XML document.
+func rootElement(body []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to read feed: %w", err)
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// covers reports whether a feed is limited to a set of locations that
+// includes location.
+func (f AlertFeed) covers(location Location) bool {
+	if len(f.Locations) == 0 {
+		return true
+	}
+
+	for _, name := range f.Locations {
+		if name == location.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matching returns the alerts whose area includes location, in the language
+// given, if any.
+func (a *capAlert) matching(location Location, language string) []Alert {
+	var alerts []Alert
+
+	for _, info := range a.Info {
+		// CAP defaults the language to en-US.
+		lang := info.Language
+		if lang == "" {
+			lang = "en-US"
+		}
+
+		if language != "" && !strings.HasPrefix(strings.ToLower(lang), strings.ToLower(language)) {
+			continue
+		}
+
+		if !info.covers(location) {
+			continue
+		}
+
+		alert := Alert{
+			Event:     info.Event,
+			Severity:  info.Severity,
+			Urgency:   info.Urgency,
+			Certainty: info.Certainty,
+		}
+
+		if t, err := time.Parse(time.RFC3339, info.Onset); err == nil {
+			alert.Onset = int(t.Unix())
+		}
+		if t, err := time.Parse(time.RFC3339, info.Expires); err == nil {
+			alert.Expires = int(t.Unix())
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts
+}
+
+// covers reports whether any area of the info includes location, either by
+// one of its geocodes or by its position.
+func (i capInfo) covers(location Location) bool {
+	for _, area := range i.Area {
+		for _, g := range area.Geocode {
+			for _, code := range location.Geocodes {
+				if code == g.ValueName+"="+g.Value {
+					return true
+				}
+			}
+		}
+
+		for _, p := range area.Polygon {
+			if inPolygon(location.Latitude, location.Longitude, capPoints(p)) {
+				return true
+			}
+		}
+
+		for _, c := range area.Circle {
+			fields := strings.Fields(c)
+			if len(fields) != 2 {
+				continue
+			}
+
+			centre := capPoints(fields[0])
+			radius, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil || len(centre) != 1 {
+				continue
+			}
+
+			if distanceKm(location.Latitude, location.Longitude, centre[0][0], centre[0][1]) <= radius {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// capPoints parses the space separated latitude,longitude pairs of a CAP
+// polygon or circle.
+func capPoints(s string) [][2]float64 {
+	var points [][2]float64
+	for _, pair := range strings.Fields(s) {
+		parts := strings.Split(pair, ",")
+		if len(parts) != 2 {
+			continue
+		}
+
+		lat, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			continue
+		}
+		lon, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			continue
+		}
+
+		points = append(points, [2]float64{lat, lon})
+	}
+
+	return points
+}
+
+// inPolygon reports whether the point is inside the polygon of latitude,
+// longitude vertices, by ray casting.
+func inPolygon(lat, lon float64, polygon [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a[0] > lat) != (b[0] > lat) && lon < (b[1]-a[1])*(lat-a[0])/(b[0]-a[0])+a[1] {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// distanceKm returns the great circle distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := (lat2 - lat1) * deg2rad
+	dLon := (lon2 - lon1) * deg2rad
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1*deg2rad)*math.Cos(lat2*deg2rad)*math.Pow(math.Sin(dLon/2), 2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
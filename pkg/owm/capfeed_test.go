@@ -0,0 +1,141 @@
+package owm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectAlertFeeds(t *testing.T) {
+	expires := time.Now().Add(6 * time.Hour).Format(time.RFC3339)
+	expired := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <link rel="alternate" type="text/html" href="https://example.com/alert/1.html"/>
+    <link rel="related" type="application/cap+xml" href="https://example.com/alert/1.cap"/>
+  </entry>
+  <entry>
+    <content type="text/xml">
+      <alert xmlns="urn:oasis:names:tc:emergency:cap:1.2">
+        <identifier>2</identifier>
+        <status>Actual</status>
+        <msgType>Alert</msgType>
+        <info>
+          <language>en-GB</language>
+          <event>Yellow wind warning</event>
+          <urgency>Expected</urgency>
+          <severity>Moderate</severity>
+          <certainty>Likely</certainty>
+          <expires>` + expires + `</expires>
+          <area><areaDesc>Côte-d'Or</areaDesc><geocode><valueName>EMMA_ID</valueName><value>FR421</value></geocode></area>
+        </info>
+        <info>
+          <language>fr-FR</language>
+          <event>Vigilance jaune vent</event>
+          <urgency>Expected</urgency>
+          <severity>Moderate</severity>
+          <certainty>Likely</certainty>
+          <expires>` + expires + `</expires>
+          <area><areaDesc>Côte-d'Or</areaDesc><geocode><valueName>EMMA_ID</valueName><value>FR421</value></geocode></area>
+        </info>
+      </alert>
+    </content>
+  </entry>
+  <entry>
+    <content type="text/xml">
+      <alert xmlns="urn:oasis:names:tc:emergency:cap:1.2">
+        <identifier>3</identifier>
+        <status>Actual</status>
+        <msgType>Alert</msgType>
+        <info>
+          <event>Frost</event>
+          <expires>` + expired + `</expires>
+          <area><circle>47.3,5.0 50</circle></area>
+        </info>
+      </alert>
+    </content>
+  </entry>
+</feed>`
+
+	cap := `<?xml version="1.0" encoding="UTF-8"?>
+<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2">
+  <identifier>1</identifier>
+  <status>Actual</status>
+  <msgType>Alert</msgType>
+  <info>
+    <event>Thunderstorm</event>
+    <urgency>Immediate</urgency>
+    <severity>Severe</severity>
+    <certainty>Observed</certainty>
+    <expires>` + expires + `</expires>
+    <area><polygon>47,4.5 47,5.5 48,5.5 48,4.5 47,4.5</polygon></area>
+  </info>
+</alert>`
+
+	requests := make(map[string]int)
+	o := newTestOWM(Config{
+		AlertFeeds: []AlertFeed{{Name: "meteoalarm", URL: "https://example.com/feed", Language: "en"}},
+		Locations: []Location{
+			{Name: "dijon", Latitude: 47.32, Longitude: 5.04, Geocodes: []string{"EMMA_ID=FR421"}},
+			{Name: "paris", Latitude: 48.86, Longitude: 2.35},
+		},
+	}, func(req *http.Request) (*http.Response, error) {
+		requests[req.URL.Path]++
+
+		switch req.URL.Path {
+		case "/feed":
+			return jsonResponse(feed), nil
+		case "/alert/1.cap":
+			return jsonResponse(cap), nil
+		}
+
+		t.Fatalf("unexpected request %s", req.URL)
+		return nil, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		ch := make(chan prometheus.Metric, 10)
+		o.collectAlertFeeds(context.Background(), ch)
+		close(ch)
+
+		// Labels are sorted by name: certainty, event, location, provider,
+		// severity, urgency.
+		var events []string
+		for m := range ch {
+			pb := &dto.Metric{}
+			require.NoError(t, m.Write(pb))
+
+			labels := pb.GetLabel()
+			require.Equal(t, "dijon", labels[2].GetValue())
+			require.Equal(t, "meteoalarm", labels[3].GetValue())
+			events = append(events, labels[1].GetValue())
+		}
+
+		require.ElementsMatch(t, []string{"Thunderstorm", "Yellow wind warning"}, events)
+	}
+
+	// Linked alerts are read once.
+	require.Equal(t, 2, requests["/feed"])
+	require.Equal(t, 1, requests["/alert/1.cap"])
+}
+
+func TestInPolygon(t *testing.T) {
+	square := capPoints("0,0 0,10 10,10 10,0 0,0")
+	require.Len(t, square, 5)
+
+	require.True(t, inPolygon(5, 5, square))
+	require.False(t, inPolygon(5, 15, square))
+	require.False(t, inPolygon(-1, 5, square))
+}
+
+func TestDistanceKm(t *testing.T) {
+	// Paris to London.
+	require.InDelta(t, 343.5, distanceKm(48.8566, 2.3522, 51.5074, -0.1278), 1)
+}
@@ -0,0 +1,114 @@
+package owm
+
+import (
+	"math"
+)
+
+// addComfort adds the comfort indices derived from the temperature, humidity
+// and wind speed to conditions, where those are given.
+func addComfort(conditions map[string]float64) {
+	t, ok := conditions["temp"]
+	if !ok {
+		return
+	}
+
+	wind, hasWind := conditions["wind_speed"]
+	if hasWind {
+		conditions["wind_chill"] = windChill(t, wind)
+	}
+
+	rh, ok := conditions["humidity"]
+	if !ok {
+		return
+	}
+
+	conditions["heat_index"] = heatIndex(t, rh)
+	conditions["absolute_humidity"] = absoluteHumidity(t, rh)
+
+	dewPoint, ok := conditions["dew_point"]
+	if !ok && rh > 0 {
+		dewPoint, ok = dewPointFromHumidity(t, rh), true
+	}
+	if ok {
+		conditions["humidex"] = humidex(t, dewPoint)
+	}
+
+	if hasWind {
+		conditions["apparent_temperature"] = apparentTemperature(t, rh, wind)
+	}
+}
+
+// heatIndex returns the NWS heat index in °C for the temperature in °C and the
+// relative humidity in percent, using the Rothfusz regression and its
+// adjustments above 80°F, and Steadman's simpler formula below.
+func heatIndex(t, rh float64) float64 {
+	f := celsiusToFahrenheit(t)
+
+	hi := 0.5 * (f + 61 + (f-68)*1.2 + rh*0.094)
+	if (hi+f)/2 < 80 {
+		return fahrenheitToCelsius(hi)
+	}
+
+	hi = -42.379 + 2.04901523*f + 10.14333127*rh -
+		0.22475541*f*rh - 0.00683783*f*f - 0.05481717*rh*rh +
+		0.00122874*f*f*rh + 0.00085282*f*rh*rh - 0.00000199*f*f*rh*rh
+
+	switch {
+	case rh < 13 && f >= 80 && f <= 112:
+		hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(f-95))/17)
+	case rh > 85 && f >= 80 && f <= 87:
+		hi += (rh - 85) / 10 * (87 - f) / 5
+	}
+
+	return fahrenheitToCelsius(hi)
+}
+
+// windChill returns the wind chill index in °C used by the NWS and
+// Environment Canada for the temperature in °C and the wind speed in m/s.
+// Outside the conditions it is defined for, temperatures at or below 10°C and
+// winds above 4.8 km/h, it is the air temperature.
+func windChill(t, wind float64) float64 {
+	v := wind * 3.6
+	if t > 10 || v <= 4.8 {
+		return t
+	}
+
+	p := math.Pow(v, 0.16)
+
+	return 13.12 + 0.6215*t - 11.37*p + 0.3965*t*p
+}
+
+// humidex returns the Canadian humidex for the temperature and dew point in
+// °C.
+func humidex(t, dewPoint float64) float64 {
+	e := 6.11 * math.Exp(5417.7530*(1/273.16-1/(kelvin+dewPoint)))
+
+	return t + 0.5555*(e-10)
+}
+
+// apparentTemperature returns the Australian Bureau of Meteorology apparent
+// temperature in °C, without radiation, for the temperature in °C, the
+// relative humidity in percent and the wind speed in m/s.
+func apparentTemperature(t, rh, wind float64) float64 {
+	e := rh / 100 * 6.105 * math.Exp(17.27*t/(237.7+t))
+
+	return t + 0.33*e - 0.70*wind - 4.00
+}
+
+// absoluteHumidity returns the mass of water vapour in g/m³ for the
+// temperature in °C and the relative humidity in percent.
+func absoluteHumidity(t, rh float64) float64 {
+	return 6.112 * math.Exp(17.67*t/(t+243.5)) * rh * 2.1674 / (kelvin + t)
+}
+
+// dewPointFromHumidity returns the dew point in °C for the temperature in °C
+// and the relative humidity in percent, using the Magnus formula.
+func dewPointFromHumidity(t, rh float64) float64 {
+	gamma := math.Log(rh/100) + 17.62*t/(243.12+t)
+
+	return 243.12 * gamma / (17.62 - gamma)
+}
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
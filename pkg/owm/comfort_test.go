@@ -0,0 +1,88 @@
+package owm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeatIndex(t *testing.T) {
+	// NWS heat index chart, in °F.
+	cases := []struct {
+		t, rh, hi float64
+	}{
+		{80, 40, 80},
+		{90, 60, 100},
+		{96, 55, 112},
+		{100, 40, 109},
+		{86, 90, 105},
+		{104, 10, 98},
+	}
+
+	for _, tc := range cases {
+		hi := celsiusToFahrenheit(heatIndex(fahrenheitToCelsius(tc.t), tc.rh))
+		require.InDelta(t, tc.hi, hi, 1, "%v°F %v%%", tc.t, tc.rh)
+	}
+}
+
+func TestWindChill(t *testing.T) {
+	// Environment Canada wind chill chart, temperature in °C and wind in
+	// km/h.
+	cases := []struct {
+		t, wind, chill float64
+	}{
+		{5, 10, 3},
+		{-10, 20, -18},
+		{-20, 30, -33},
+		{-30, 60, -50},
+	}
+
+	for _, tc := range cases {
+		require.InDelta(t, tc.chill, windChill(tc.t, tc.wind/3.6), 0.6, "%v°C %vkm/h", tc.t, tc.wind)
+	}
+
+	// Undefined above 10°C and in light winds.
+	require.Equal(t, 15.0, windChill(15, 10))
+	require.Equal(t, -5.0, windChill(-5, 1))
+}
+
+func TestHumidex(t *testing.T) {
+	// Environment Canada humidex chart, temperature and dew point in °C.
+	cases := []struct {
+		t, dewPoint, humidex float64
+	}{
+		{30, 20, 37},
+		{35, 25, 48},
+		{25, 15, 29},
+	}
+
+	for _, tc := range cases {
+		require.InDelta(t, tc.humidex, humidex(tc.t, tc.dewPoint), 1, "%v°C %v°C", tc.t, tc.dewPoint)
+	}
+}
+
+func TestApparentTemperature(t *testing.T) {
+	// Bureau of Meteorology apparent temperature without radiation.
+	require.InDelta(t, 24.8, apparentTemperature(25, 50, 2), 0.1)
+	require.InDelta(t, 5.7, apparentTemperature(10, 80, 5), 0.1)
+}
+
+func TestAbsoluteHumidity(t *testing.T) {
+	// Saturation vapour density is 17.3 g/m³ at 20°C and 30.4 g/m³ at 30°C.
+	require.InDelta(t, 8.65, absoluteHumidity(20, 50), 0.05)
+	require.InDelta(t, 30.4, absoluteHumidity(30, 100), 0.2)
+}
+
+func TestAddComfort(t *testing.T) {
+	conditions := map[string]float64{"temp": 5, "humidity": 70, "wind_speed": 5}
+	addComfort(conditions)
+
+	for _, condition := range []string{"heat_index", "wind_chill", "humidex", "apparent_temperature", "absolute_humidity"} {
+		require.Contains(t, conditions, condition)
+	}
+
+	// Without humidity, only the wind chill is known.
+	conditions = map[string]float64{"temp": 5, "wind_speed": 5}
+	addComfort(conditions)
+	require.Len(t, conditions, 3)
+}
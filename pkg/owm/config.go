@@ -2,26 +2,185 @@ package owm
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	yaml "gopkg.in/yaml.v2"
 )
 
+// APIMode values select which OpenWeatherMap APIs are used to collect weather.
+const (
+	// APIModeOneCall uses the One Call API only.
+	APIModeOneCall = "onecall"
+	// APIModeFree uses the Current Weather and 5 day/3 hour forecast APIs,
+	// which are available to free API keys.
+	APIModeFree = "free"
+	// APIModeAuto uses the One Call API, falling back to the free APIs when it
+	// is unavailable.
+	APIModeAuto = "auto"
+)
+
 type Config struct {
 	OtelEndpoint string `yaml:"otel_endpoint"`
 	OrgID        string `yaml:"org_id"`
 	ListenAddr   string `yaml:"listen_addr"`
+	APIMode      string `yaml:"api_mode"`
+	ForecastDays int    `yaml:"forecast_days"`
+	SolarAPI     bool   `yaml:"solar_api"`
+	// UserAgent identifies the exporter to providers that require it, eg:
+	// nws.  It should include contact details.
+	UserAgent string `yaml:"user_agent"`
+	// METNorwayFormat selects the compact or complete locationforecast.
+	METNorwayFormat string `yaml:"met_norway_format"`
+
+	// Evapotranspiration adds the FAO-56 reference evapotranspiration to the
+	// forecasts and exports the daily water deficit.
+	Evapotranspiration bool `yaml:"evapotranspiration"`
+
+	// WBGT adds the wet-bulb globe temperature to the current conditions and
+	// forecasts, exporting the heat stress category of each.
+	WBGT bool `yaml:"wbgt"`
+	// WBGTThresholds are the ascending WBGT in °C that start each heat stress
+	// category, defaulting to the US military heat flags.
+	WBGTThresholds []float64 `yaml:"wbgt_thresholds"`
+
+	// ForecastWindows are the periods from now, in whole hours, over which
+	// statistics of the hourly forecast are exported, eg: [6h, 24h].
+	ForecastWindows []time.Duration `yaml:"forecast_windows"`
+
+	// PrecipitationTotals accumulates the observed hourly rain and snow.
+	PrecipitationTotals bool `yaml:"precipitation_totals"`
+
+	// PressureTendency exports the tendency of the current pressure over 3
+	// hours, flagging drops of at least PressureDropThreshold in hPa.
+	PressureTendency      bool    `yaml:"pressure_tendency"`
+	PressureDropThreshold float64 `yaml:"pressure_drop_threshold"`
+
+	DegreeDays  bool    `yaml:"degree_days"`
+	HeatingBase float64 `yaml:"heating_base"`
+	CoolingBase float64 `yaml:"cooling_base"`
+
+	ForecastAccuracy     bool `yaml:"forecast_accuracy"`
+	AccuracyWindow       int  `yaml:"accuracy_window"`
+	ForecastCorrection   bool `yaml:"forecast_correction"`
+	CorrectionMinSamples int  `yaml:"correction_min_samples"`
 
 	APIKey    string     `mapstructure:"apikey"`
 	Locations []Location `mapstructure:"locations"`
+
+	AgroAPIKey string    `yaml:"agro_api_key"`
+	Polygons   []Polygon `yaml:"polygons"`
+
+	PrometheusURL   string        `yaml:"prometheus_url"`
+	StationInterval time.Duration `yaml:"station_interval"`
+	Stations        []Station     `yaml:"stations"`
+
+	LocalStations []LocalStation `yaml:"local_stations"`
+
+	AlertFeeds []AlertFeed `yaml:"alert_feeds"`
+
+	// StateFile keeps accumulations, eg: growing degree days, across
+	// restarts.
+	StateFile string `yaml:"state_file"`
 }
 
+// Location is a place to collect weather for.  Locations with a CityID and
+// no coordinates are collected in batches through the group endpoint, which
+// reports current conditions only.
 type Location struct {
 	Name      string
 	Latitude  float64
 	Longitude float64
+	// Altitude is the height above sea level in metres, used by met-norway
+	// to adjust the forecast temperature.
+	Altitude float64 `yaml:"altitude"`
+	CityID   int     `yaml:"city_id"`
+	PV       *PV     `yaml:"pv"`
+	// Station is the name of a local station whose observations are used in
+	// place of the current conditions to score forecasts.
+	Station string `yaml:"station"`
+	// Providers are the weather providers to collect from, defaulting to
+	// openweathermap.
+	Providers []string `yaml:"providers"`
+	// GDD enables growing degree days for the location.
+	GDD *GDD `yaml:"gdd"`
+	// Frost enables the frost risk forecast for the location.
+	Frost bool `yaml:"frost"`
+	// FWI enables the Canadian Forest Fire Weather Index for the location.
+	FWI bool `yaml:"fwi"`
+	// Geocodes match the location to the areas of CAP alerts, given as
+	// valueName=value, eg: EMMA_ID=FR433.
+	Geocodes []string `yaml:"geocodes"`
+}
+
+// Polygon is a field registered with the Agro API, given either as GeoJSON or
+// as a list of [longitude, latitude] coordinates.
+type Polygon struct {
+	Name        string      `yaml:"name"`
+	GeoJSON     string      `yaml:"geojson"`
+	Coordinates [][]float64 `yaml:"coordinates"`
+}
+
+// Station is a personal weather station whose measurements are uploaded to
+// the Stations API.  Measurements are read from Prometheus using Queries, keyed
+// by measurement name, eg: temperature, and from readings pushed to the
+// exporter.
+type Station struct {
+	ExternalID string            `yaml:"external_id"`
+	Name       string            `yaml:"name"`
+	Latitude   float64           `yaml:"latitude"`
+	Longitude  float64           `yaml:"longitude"`
+	Altitude   float64           `yaml:"altitude"`
+	Queries    map[string]string `yaml:"queries"`
+}
+
+// LocalStation is a weather station pushing observations to the exporter
+// using the Ecowitt or Ambient Weather custom server protocols.
+type LocalStation struct {
+	Name string `yaml:"name"`
+	// Key identifies the station, matching the PASSKEY or MAC it sends.
+	Key string `yaml:"key"`
+}
+
+// AlertFeed is a feed of CAP 1.2 alerts, given either as a single CAP document
+// or as an Atom or RSS feed of them.  Alerts are matched to locations by the
+// polygons, circles and geocodes of their areas.
+type AlertFeed struct {
+	// Name is the value of the provider label of the alerts.
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Language limits alerts given in several languages to one, eg: en.
+	Language string `yaml:"language"`
+	// Locations limits the feed to the named locations, defaulting to all.
+	Locations []string `yaml:"locations"`
+}
+
+// GDD configures the growing degree days of a location.
+type GDD struct {
+	// Base is the temperature in °C below which there is no growth, eg: 10.
+	Base float64 `yaml:"base"`
+	// Upper is the temperature in °C above which growth no longer increases,
+	// eg: 30.  Zero disables it.
+	Upper float64 `yaml:"upper"`
+	// SeasonStart is the month and day the season starts, eg: 04-01,
+	// defaulting to 01-01.
+	SeasonStart string `yaml:"season_start"`
+}
+
+// PV describes a photovoltaic array at a location.
+type PV struct {
+	// KWp is the peak power of the array in kilowatts.
+	KWp float64 `yaml:"kwp"`
+	// Tilt is the angle of the panels from horizontal in degrees.
+	Tilt float64 `yaml:"tilt"`
+	// Azimuth is the direction the panels face in degrees clockwise from
+	// north, eg: 180 for south.
+	Azimuth float64 `yaml:"azimuth"`
+	// Losses is the percentage of system losses, eg: 14.
+	Losses float64 `yaml:"losses"`
 }
 
 // LoadConfig receives a file path for a configuration to load.
@@ -52,8 +211,151 @@ func loadYamlFile(filename string, d interface{}) error {
 	return nil
 }
 
+// Validate checks the configuration for values the exporter cannot use.
+func (c *Config) Validate() error {
+	switch c.APIMode {
+	case "", APIModeOneCall, APIModeFree, APIModeAuto:
+	default:
+		return fmt.Errorf("unknown api mode %q", c.APIMode)
+	}
+
+	switch c.METNorwayFormat {
+	case "", metNorwayCompact, metNorwayComplete:
+	default:
+		return fmt.Errorf("unknown met norway format %q", c.METNorwayFormat)
+	}
+
+	if c.ForecastDays < 0 || c.ForecastDays > maxForecastDays {
+		return fmt.Errorf("forecast days must be between 0 and %d", maxForecastDays)
+	}
+
+	for _, l := range c.Locations {
+		if l.CityID > 0 && l.Name == "" {
+			return fmt.Errorf("location with city_id %d has no name", l.CityID)
+		}
+
+		for _, p := range l.Providers {
+			if !knownProviders[p] {
+				return fmt.Errorf("location %s has unknown provider %q", l.Name, p)
+			}
+
+			if userAgentProviders[p] && c.UserAgent == "" {
+				return fmt.Errorf("location %s uses provider %s, which requires a user_agent", l.Name, p)
+			}
+		}
+
+		if g := l.GDD; g != nil {
+			if g.Upper > 0 && g.Upper <= g.Base {
+				return fmt.Errorf("location %s has a gdd upper threshold not above its base", l.Name)
+			}
+
+			if g.SeasonStart != "" {
+				if _, err := time.Parse("01-02", g.SeasonStart); err != nil {
+					return fmt.Errorf("location %s has an invalid gdd season_start %q, eg: 04-01", l.Name, g.SeasonStart)
+				}
+			}
+		}
+	}
+
+	polygons := make(map[string]bool, len(c.Polygons))
+	for _, p := range c.Polygons {
+		if p.Name == "" {
+			return fmt.Errorf("polygon has no name")
+		}
+
+		if polygons[p.Name] {
+			return fmt.Errorf("polygon %s is configured more than once", p.Name)
+		}
+		polygons[p.Name] = true
+	}
+
+	for _, w := range c.ForecastWindows {
+		if w < time.Hour || w%time.Hour != 0 {
+			return fmt.Errorf("forecast window %s is not a whole number of hours", w)
+		}
+	}
+
+	for i := 1; i < len(c.WBGTThresholds); i++ {
+		if c.WBGTThresholds[i] <= c.WBGTThresholds[i-1] {
+			return fmt.Errorf("wbgt thresholds must be ascending")
+		}
+	}
+
+	if c.PressureTendency && c.PressureDropThreshold <= 0 {
+		return fmt.Errorf("pressure drop threshold must be positive")
+	}
+
+	if c.DegreeDays && c.HeatingBase > c.CoolingBase {
+		return fmt.Errorf("heating base must not be above the cooling base")
+	}
+
+	if (c.ForecastAccuracy || c.ForecastCorrection) && c.AccuracyWindow <= 0 {
+		return fmt.Errorf("accuracy window must be positive")
+	}
+
+	for _, s := range c.Stations {
+		if s.ExternalID == "" {
+			return fmt.Errorf("station %q has no external_id", s.Name)
+		}
+
+		if len(s.Queries) > 0 && c.PrometheusURL == "" {
+			return fmt.Errorf("station %s has queries but no prometheus_url is set", s.ExternalID)
+		}
+
+		for measurement := range s.Queries {
+			if !stationMeasurements[measurement] {
+				return fmt.Errorf("station %s has unknown measurement %q", s.ExternalID, measurement)
+			}
+		}
+	}
+
+	for _, s := range c.LocalStations {
+		if s.Name == "" || s.Key == "" {
+			return fmt.Errorf("local stations need both a name and a key")
+		}
+	}
+
+	feeds := make(map[string]bool, len(c.AlertFeeds))
+	for _, f := range c.AlertFeeds {
+		if f.Name == "" || f.URL == "" {
+			return fmt.Errorf("alert feeds need both a name and a url")
+		}
+
+		if feeds[f.Name] || knownProviders[f.Name] {
+			return fmt.Errorf("alert feed name %q is already in use", f.Name)
+		}
+		feeds[f.Name] = true
+	}
+
+	if len(c.Stations) > 0 && c.StationInterval <= 0 {
+		return fmt.Errorf("station interval must be positive")
+	}
+
+	return nil
+}
+
 func (c *Config) RegisterFlagsAndApplyDefaults(prefix string, f *flag.FlagSet) {
 	f.StringVar(&c.OtelEndpoint, "otel.endpoint", "", "otel endpoint, eg: tempo:4317")
 	f.StringVar(&c.OrgID, "org.id", "", "org ID to use when sending traces")
 	f.StringVar(&c.ListenAddr, "listen.addr", ":9101", "address to listen on")
+	f.StringVar(&c.APIMode, "api.mode", APIModeOneCall, "weather APIs to use: onecall, free or auto")
+	f.StringVar(&c.UserAgent, "user.agent", "", "User-Agent sent to providers that require one, eg: \"exporter (me@example.com)\"")
+	f.StringVar(&c.METNorwayFormat, "metno.format", metNorwayCompact, "MET Norway locationforecast format: compact or complete")
+	f.BoolVar(&c.SolarAPI, "solar.api", false, "read solar irradiance from the Solar Irradiance API rather than estimating it")
+	f.StringVar(&c.PrometheusURL, "prometheus.url", "", "Prometheus server to read station measurements from, eg: http://prometheus:9090")
+	f.DurationVar(&c.StationInterval, "station.interval", 5*time.Minute, "interval between station measurement uploads")
+	f.BoolVar(&c.ForecastAccuracy, "forecast.accuracy", false, "score forecasts against the observed conditions")
+	f.IntVar(&c.AccuracyWindow, "forecast.accuracy-window", 168, "number of scored forecasts per location, condition and lead time to average over")
+	f.BoolVar(&c.ForecastCorrection, "forecast.correction", false, "export forecasts corrected for the bias found by scoring them against the observed conditions")
+	f.IntVar(&c.CorrectionMinSamples, "forecast.correction-min-samples", 24, "number of scored forecasts needed before a forecast is corrected")
+	f.BoolVar(&c.Evapotranspiration, "forecast.et0", false, "forecast FAO-56 reference evapotranspiration and the water deficit")
+	f.BoolVar(&c.WBGT, "wbgt", false, "estimate the wet-bulb globe temperature and export heat stress categories")
+	f.BoolVar(&c.PrecipitationTotals, "precipitation.totals", false, "export rain and snow accumulated from the current conditions")
+	f.BoolVar(&c.PressureTendency, "pressure.tendency", false, "export the 3 hour tendency of the current pressure")
+	f.Float64Var(&c.PressureDropThreshold, "pressure.rapid-drop", 3.6, "fall in hPa over 3 hours reported as a rapid pressure drop")
+	f.BoolVar(&c.DegreeDays, "degree-days", false, "export heating and cooling degree days and forecast degree hours")
+	f.Float64Var(&c.HeatingBase, "degree-days.heating-base", 15.5, "base temperature in °C below which heating degree days accumulate")
+	f.Float64Var(&c.CoolingBase, "degree-days.cooling-base", 22, "base temperature in °C above which cooling degree days accumulate")
+	f.StringVar(&c.StateFile, "state.file", "", "file to keep accumulations, eg: growing degree days, in across restarts")
+	f.IntVar(&c.ForecastDays, "forecast.days", 0, "number of days of long range daily forecast to collect, up to 16; 0 disables")
 }
@@ -0,0 +1,105 @@
+package owm
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	metricWeatherForecastCorrectedDesc = prometheus.NewDesc(
+		"weather_forecast_corrected",
+		"Weather condition forecast, corrected for the local forecast bias",
+		[]string{"location", "provider", "condition", "future_hours"},
+		nil,
+	)
+
+	metricForecastCorrectionOffsetDesc = prometheus.NewDesc(
+		"weather_forecast_correction_offset",
+		"Offset added to the forecast by the bias correction",
+		[]string{"location", "provider", "condition", "lead_time"},
+		nil,
+	)
+)
+
+// percentConditions are bounded to 0-100 after correction.
+var percentConditions = map[string]bool{
+	"clouds":   true,
+	"humidity": true,
+}
+
+// correction returns the offset to add to a forecast of condition from a
+// provider for a location at the lead time, and whether enough forecasts have
+// been scored to correct it.
+func (o *OWM) correction(key accuracyKey) (float64, bool) {
+	_, bias, n := o.accuracy.stats(key)
+	if n < o.cfg.CorrectionMinSamples {
+		return 0, false
+	}
+
+	return -bias, true
+}
+
+// emitCorrected sends the bias corrected forecast conditions for a location.
+func (o *OWM) emitCorrected(ch chan<- prometheus.Metric, provider string, location Location, now time.Time, hours []Forecast) {
+	o.accuracy.mtx.Lock()
+	defer o.accuracy.mtx.Unlock()
+
+	for _, hour := range hours {
+		lead := time.Unix(int64(hour.Dt), 0).Round(time.Hour).Sub(now).Hours()
+		if lead <= 0 {
+			continue
+		}
+
+		for _, condition := range scoredConditions {
+			value, ok := hour.Conditions[condition]
+			if !ok {
+				continue
+			}
+
+			offset, ok := o.correction(accuracyKey{provider, location.Name, condition, leadTime(lead)})
+			if !ok {
+				continue
+			}
+
+			value += offset
+			if percentConditions[condition] {
+				value = math.Max(0, math.Min(100, value))
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				metricWeatherForecastCorrectedDesc,
+				prometheus.GaugeValue,
+				value,
+				location.Name,
+				provider,
+				condition,
+				futureHours(hour.Dt),
+			)
+		}
+	}
+}
+
+// collectCorrection sends the offsets applied by the bias correction.
+func (o *OWM) collectCorrection(ch chan<- prometheus.Metric) {
+	o.accuracy.mtx.Lock()
+	defer o.accuracy.mtx.Unlock()
+
+	for key := range o.accuracy.errors {
+		offset, ok := o.correction(key)
+		if !ok {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			metricForecastCorrectionOffsetDesc,
+			prometheus.GaugeValue,
+			offset,
+			key.location,
+			key.provider,
+			key.condition,
+			key.leadTime,
+		)
+	}
+}
@@ -0,0 +1,166 @@
+package owm
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// maxDegreeDayGap is the longest interval between observations that is
+	// integrated.  Longer gaps, eg: while the exporter was down, are skipped.
+	maxDegreeDayGap = 3 * time.Hour
+
+	// degreeHoursAhead is how far ahead forecast degree hours are summed.
+	degreeHoursAhead = 48 * time.Hour
+)
+
+var (
+	metricDegreeDaysDesc = prometheus.NewDesc(
+		"weather_degree_days",
+		"Degree days accumulated since local midnight from the current temperature: (heating|cooling)",
+		[]string{"location", "provider", "type"},
+		nil,
+	)
+
+	metricForecastDegreeHoursDesc = prometheus.NewDesc(
+		"weather_forecast_degree_hours",
+		"Degree hours forecast over the next 48 hours: (heating|cooling)",
+		[]string{"location", "provider", "type"},
+		nil,
+	)
+)
+
+// degreeDays is the accumulation for the local day of a location.
+type degreeDays struct {
+	day     string
+	last    time.Time
+	temp    float64
+	heating float64
+	cooling float64
+}
+
+// add integrates the mean temperature since the last observation into the
+// day's degree days, starting a new day at local midnight.  An interval that
+// spans midnight is split there, with the temperature at midnight
+// interpolated, so that each day is credited with its own part.
+func (d *degreeDays) add(t time.Time, temp, heatingBase, coolingBase float64) {
+	// The same observation is read on every collection.
+	if d.day != "" && !t.After(d.last) {
+		return
+	}
+
+	day := t.Format("2006-01-02")
+	if day != d.day {
+		midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		if d.day == "" || t.Sub(d.last) > maxDegreeDayGap || !d.last.Before(midnight) {
+			*d = degreeDays{day: day, last: t, temp: temp}
+			return
+		}
+
+		// The part before midnight completes the previous day.
+		frac := float64(midnight.Sub(d.last)) / float64(t.Sub(d.last))
+		atMidnight := d.temp + (temp-d.temp)*frac
+		d.integrate(midnight, atMidnight, heatingBase, coolingBase)
+
+		*d = degreeDays{day: day, last: midnight, temp: atMidnight}
+	}
+
+	if t.Sub(d.last) <= maxDegreeDayGap {
+		d.integrate(t, temp, heatingBase, coolingBase)
+	}
+
+	d.last, d.temp = t, temp
+}
+
+// integrate adds the degree days of the mean temperature from the last
+// observation to t.
+func (d *degreeDays) integrate(t time.Time, temp, heatingBase, coolingBase float64) {
+	mean := (d.temp + temp) / 2
+	days := t.Sub(d.last).Hours() / 24
+
+	d.heating += math.Max(0, heatingBase-mean) * days
+	d.cooling += math.Max(0, mean-coolingBase) * days
+}
+
+// emitDegreeDays sends the degree days accumulated from the current
+// temperature and the degree hours forecast for a location.
+func (o *OWM) emitDegreeDays(ch chan<- prometheus.Metric, provider string, location Location, weather *Weather) {
+	if c := weather.Current; c != nil {
+		if temp, ok := c.Conditions["temp"]; ok {
+			key := sourceKey{provider, location.Name}
+
+			o.mtx.Lock()
+			if o.degreeDays == nil {
+				o.degreeDays = make(map[sourceKey]*degreeDays)
+			}
+
+			d, ok := o.degreeDays[key]
+			if !ok {
+				d = &degreeDays{}
+				o.degreeDays[key] = d
+			}
+
+			d.add(time.Unix(int64(c.Dt), 0).In(weather.location()), temp, o.cfg.HeatingBase, o.cfg.CoolingBase)
+			heating, cooling := d.heating, d.cooling
+			o.mtx.Unlock()
+
+			emitHeatingCooling(ch, metricDegreeDaysDesc, prometheus.CounterValue, provider, location, heating, cooling)
+		}
+	}
+
+	if len(weather.Hourly) > 0 {
+		heating, cooling := forecastDegreeHours(time.Now(), weather.Hourly, o.cfg.HeatingBase, o.cfg.CoolingBase)
+		emitHeatingCooling(ch, metricForecastDegreeHoursDesc, prometheus.GaugeValue, provider, location, heating, cooling)
+	}
+}
+
+func emitHeatingCooling(ch chan<- prometheus.Metric, desc *prometheus.Desc, valueType prometheus.ValueType, provider string, location Location, heating, cooling float64) {
+	ch <- prometheus.MustNewConstMetric(desc, valueType, heating, location.Name, provider, "heating")
+	ch <- prometheus.MustNewConstMetric(desc, valueType, cooling, location.Name, provider, "cooling")
+}
+
+// forecastDegreeHours sums the heating and cooling degree hours of the
+// forecasts over the next 48 hours from now.  Each forecast holds until the
+// next, so the 3 hourly forecasts of the free APIs are weighted accordingly.
+func forecastDegreeHours(now time.Time, hours []Forecast, heatingBase, coolingBase float64) (float64, float64) {
+	sorted := make([]Forecast, 0, len(hours))
+	for _, h := range hours {
+		if _, ok := h.Conditions["temp"]; ok {
+			sorted = append(sorted, h)
+		}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Dt < sorted[j].Dt })
+
+	end := now.Add(degreeHoursAhead)
+	step := time.Hour
+
+	var heating, cooling float64
+	for i, h := range sorted {
+		start := time.Unix(int64(h.Dt), 0)
+		if i+1 < len(sorted) {
+			step = time.Unix(int64(sorted[i+1].Dt), 0).Sub(start)
+		}
+
+		// Clip the period to the next 48 hours.
+		from, to := start, start.Add(step)
+		if from.Before(now) {
+			from = now
+		}
+		if to.After(end) {
+			to = end
+		}
+		if !to.After(from) {
+			continue
+		}
+
+		temp := h.Conditions["temp"]
+		heating += math.Max(0, heatingBase-temp) * to.Sub(from).Hours()
+		cooling += math.Max(0, temp-coolingBase) * to.Sub(from).Hours()
+	}
+
+	return heating, cooling
+}
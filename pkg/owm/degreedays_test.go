@@ -0,0 +1,76 @@
+package owm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDegreeDays(t *testing.T) {
+	tz, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+
+	start := time.Date(2022, 1, 10, 22, 0, 0, 0, tz)
+
+	d := &degreeDays{}
+	d.add(start, 10, 15.5, 22)
+	// The same observation again is not counted.
+	d.add(start, 10, 15.5, 22)
+	// Two hours at a mean of 9.5°C, 6 degrees below the base.
+	d.add(start.Add(2*time.Hour-time.Minute), 9, 15.5, 22)
+	require.InDelta(t, 6*(119.0/60)/24, d.heating, 1e-9)
+	require.Equal(t, 0.0, d.cooling)
+
+	// Local midnight starts a new day.
+	d.add(start.Add(2*time.Hour), 9, 15.5, 22)
+	require.Equal(t, 0.0, d.heating)
+
+	// Gaps are skipped.
+	d.add(start.Add(8*time.Hour), 9, 15.5, 22)
+	require.Equal(t, 0.0, d.heating)
+	d.add(start.Add(9*time.Hour), 12, 15.5, 22)
+	require.InDelta(t, 5.0/24, d.heating, 1e-9)
+	d.add(start.Add(10*time.Hour), 30, 15.5, 22)
+	require.InDelta(t, 5.0/24, d.heating, 1e-9)
+	require.Equal(t, 0.0, d.cooling)
+	d.add(start.Add(11*time.Hour), 30, 15.5, 22)
+	require.InDelta(t, 8.0/24, d.cooling, 1e-9)
+}
+
+func TestForecastDegreeHours(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	var hours []Forecast
+	for i := 0; i < 20; i++ {
+		// 3 hourly forecasts, the first starting an hour ago.
+		dt := now.Add(time.Duration(3*i-1) * time.Hour)
+		hours = append(hours, Forecast{Dt: int(dt.Unix()), Conditions: map[string]float64{"temp": 25}})
+	}
+
+	heating, cooling := forecastDegreeHours(now, hours, 15.5, 22)
+	require.Equal(t, 0.0, heating)
+	require.InDelta(t, 3*48.0, cooling, 1e-9)
+}
+
+func TestDegreeDaysMidnight(t *testing.T) {
+	tz, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+
+	d := &degreeDays{}
+	d.add(time.Date(2022, 1, 10, 23, 0, 0, 0, tz), 11.5, 15.5, 22)
+	d.add(time.Date(2022, 1, 10, 23, 30, 0, 0, tz), 9.5, 15.5, 22)
+
+	// An hour from 9.5°C to 7.5°C, half of it after midnight at a mean of
+	// 8°C, 7.5 degrees below the base.
+	d.add(time.Date(2022, 1, 11, 0, 30, 0, 0, tz), 7.5, 15.5, 22)
+	require.Equal(t, "2022-01-11", d.day)
+	require.InDelta(t, 7.5*0.5/24, d.heating, 1e-9)
+
+	d.add(time.Date(2022, 1, 11, 1, 30, 0, 0, tz), 7.5, 15.5, 22)
+	require.InDelta(t, (7.5*0.5+8)/24, d.heating, 1e-9)
+}
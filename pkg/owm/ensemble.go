@@ -0,0 +1,163 @@
+package owm
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metricWeatherForecastEnsembleDesc = prometheus.NewDesc(
+	"weather_forecast_ensemble",
+	"Weather condition forecast across providers: (mean|min|max|spread)",
+	[]string{"location", "condition", "future_hours", "stat"},
+	nil,
+)
+
+// circularConditions are the conditions given as a bearing in degrees.
+var circularConditions = map[string]bool{
+	"wind_degree": true,
+}
+
+// ensembleKey is a condition forecast for the hour starting at the unix time
+// hour.
+type ensembleKey struct {
+	hour      int64
+	condition string
+}
+
+// ensembleStats collects the forecasts of several providers, one from each.
+type ensembleStats struct {
+	provider int
+	values   []float64
+}
+
+// add records the forecast of the provider at index provider, keeping the
+// first when it forecasts the hour more than once.
+func (s *ensembleStats) add(provider int, v float64) {
+	if len(s.values) > 0 && s.provider == provider {
+		return
+	}
+
+	s.provider = provider
+	s.values = append(s.values, v)
+}
+
+// linear returns the mean, min, max and spread of the forecasts.
+func (s *ensembleStats) linear() map[string]float64 {
+	min, max, sum := s.values[0], s.values[0], 0.0
+	for _, v := range s.values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+		sum += v
+	}
+
+	return map[string]float64{
+		"mean":   sum / float64(len(s.values)),
+		"min":    min,
+		"max":    max,
+		"spread": max - min,
+	}
+}
+
+// circular returns the circular mean of bearings in degrees, and the
+// smallest arc containing them: min and max are its ends clockwise, and
+// spread its size.
+func (s *ensembleStats) circular() map[string]float64 {
+	min, max, spread := bearingArc(s.values)
+
+	return map[string]float64{
+		"mean":   circularMean(s.values, nil),
+		"min":    min,
+		"max":    max,
+		"spread": spread,
+	}
+}
+
+// circularMean returns the mean of bearings in degrees, weighted by weights
+// when given.
+func circularMean(bearings, weights []float64) float64 {
+	var sin, cos float64
+	for i, b := range bearings {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+
+		sin += w * math.Sin(b*deg2rad)
+		cos += w * math.Cos(b*deg2rad)
+	}
+
+	return math.Mod(math.Atan2(sin, cos)/deg2rad+360, 360)
+}
+
+// bearingArc returns the smallest arc containing bearings in degrees, by its
+// ends clockwise and its size.
+func bearingArc(bearings []float64) (start, end, size float64) {
+	sorted := make([]float64, len(bearings))
+	for i, b := range bearings {
+		sorted[i] = math.Mod(math.Mod(b, 360)+360, 360)
+	}
+	sort.Float64s(sorted)
+
+	// The arc is the circle less the largest gap between bearings.
+	n := len(sorted)
+	gap, last := sorted[0]+360-sorted[n-1], n-1
+	for i := 0; i+1 < n; i++ {
+		if g := sorted[i+1] - sorted[i]; g > gap {
+			gap, last = g, i
+		}
+	}
+
+	return sorted[(last+1)%n], sorted[last], 360 - gap
+}
+
+// emitEnsemble sends the statistics of the forecasts from several providers
+// for a location.  Forecasts are matched by the hour they start in, and only
+// hours forecast by more than one provider are reported.
+func (o *OWM) emitEnsemble(ch chan<- prometheus.Metric, location Location, forecasts [][]Forecast) {
+	stats := make(map[ensembleKey]*ensembleStats)
+
+	for provider, hours := range forecasts {
+		for _, hour := range hours {
+			start := time.Unix(int64(hour.Dt), 0).Truncate(time.Hour).Unix()
+
+			for condition, value := range hour.Conditions {
+				key := ensembleKey{start, condition}
+
+				s, ok := stats[key]
+				if !ok {
+					s = &ensembleStats{}
+					stats[key] = s
+				}
+
+				s.add(provider, value)
+			}
+		}
+	}
+
+	for key, s := range stats {
+		if len(s.values) < 2 {
+			continue
+		}
+
+		values := s.linear()
+		if circularConditions[key.condition] {
+			values = s.circular()
+		}
+
+		future := futureHours(int(key.hour))
+		for stat, value := range values {
+			ch <- prometheus.MustNewConstMetric(
+				metricWeatherForecastEnsembleDesc,
+				prometheus.GaugeValue,
+				value,
+				location.Name,
+				key.condition,
+				future,
+				stat,
+			)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package owm
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitEnsemble(t *testing.T) {
+	o := newTestOWM(Config{}, nil)
+
+	hour := time.Now().Add(3 * time.Hour).Truncate(time.Hour)
+	later := hour.Add(time.Hour)
+
+	forecasts := [][]Forecast{
+		{
+			{Dt: int(hour.Unix()), Conditions: map[string]float64{"temp": 10, "pop": 0.2, "wind_degree": 350}},
+			// A second forecast in the hour from the same provider.
+			{Dt: int(hour.Add(30 * time.Minute).Unix()), Conditions: map[string]float64{"temp": 30, "pop": 0.4}},
+			{Dt: int(later.Unix()), Conditions: map[string]float64{"temp": 11}},
+		},
+		// Matched to the hour it starts in.
+		{{Dt: int(hour.Add(30 * time.Minute).Unix()), Conditions: map[string]float64{"temp": 14, "wind_degree": 20}}},
+		{{Dt: int(hour.Unix()), Conditions: map[string]float64{"temp": 12}}},
+	}
+
+	ch := make(chan prometheus.Metric, 100)
+	o.emitEnsemble(ch, Location{Name: "home"}, forecasts)
+	close(ch)
+
+	// Labels are sorted by name: condition, future_hours, location, stat.
+	stats := map[string]map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		require.NoError(t, m.Write(pb))
+
+		labels := pb.GetLabel()
+		require.Equal(t, futureHours(int(hour.Unix())), labels[1].GetValue())
+
+		condition := labels[0].GetValue()
+		if stats[condition] == nil {
+			stats[condition] = map[string]float64{}
+		}
+		stats[condition][labels[3].GetValue()] = pb.GetGauge().GetValue()
+	}
+
+	// Hours and conditions forecast by a single provider are left out.
+	require.Equal(t, []string{"temp", "wind_degree"}, sortedKeys(stats))
+	require.Equal(t, map[string]float64{
+		"mean":   12,
+		"min":    10,
+		"max":    14,
+		"spread": 4,
+	}, stats["temp"])
+
+	// Bearings either side of north.
+	wind := stats["wind_degree"]
+	require.InDelta(t, 5, wind["mean"], 1e-9)
+	require.Equal(t, 350.0, wind["min"])
+	require.Equal(t, 20.0, wind["max"])
+	require.Equal(t, 30.0, wind["spread"])
+}
+
+func sortedKeys(m map[string]map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
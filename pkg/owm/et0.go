@@ -0,0 +1,304 @@
+package owm
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// windHeightFactor converts wind speed at 10m to 2m, FAO-56 equation 47.
+	windHeightFactor = 0.748
+	// solarConstantMJ is the solar constant in MJ/m²/min.
+	solarConstantMJ = 0.0820
+	// wattsToMJPerHour converts irradiance in W/m² to MJ/m²/h.
+	wattsToMJPerHour = 0.0036
+)
+
+var metricWaterDeficitDesc = prometheus.NewDesc(
+	"weather_forecast_water_deficit",
+	"Forecast reference evapotranspiration less forecast rain (mm)",
+	[]string{"location", "provider", "future_days"},
+	nil,
+)
+
+// saturationVapourPressure returns the saturation vapour pressure in kPa at
+// the temperature in °C, FAO-56 equation 11.
+func saturationVapourPressure(t float64) float64 {
+	return 0.6108 * math.Exp(17.27*t/(t+237.3))
+}
+
+// atmosphericPressure returns the pressure in kPa at the altitude in metres,
+// FAO-56 equation 7.
+func atmosphericPressure(altitude float64) float64 {
+	return 101.3 * math.Pow((293-0.0065*altitude)/293, 5.26)
+}
+
+// slope returns the slope of the saturation vapour pressure curve in kPa/°C
+// at the temperature in °C, FAO-56 equation 13.
+func slope(t float64) float64 {
+	return 4098 * saturationVapourPressure(t) / math.Pow(t+237.3, 2)
+}
+
+// cloudRadiationRatio returns the ratio of solar radiation to clear sky
+// radiation for the cloud cover percentage, by Kasten and Czeplak.
+func cloudRadiationRatio(clouds float64) float64 {
+	return 1 - 0.75*math.Pow(clouds/100, 3.4)
+}
+
+// penmanMonteithHourly returns the hourly reference evapotranspiration in mm
+// by FAO-56 equation 53, from the mean temperature in °C, the actual vapour
+// pressure in kPa, the wind speed at 2m in m/s, the solar radiation in
+// MJ/m²/h, its ratio to clear sky radiation, the pressure in kPa and whether
+// it is day.
+func penmanMonteithHourly(t, ea, u2, rs, ratio, pressure float64, day bool) float64 {
+	gamma := 0.665e-3 * pressure
+
+	rnl := 2.043e-10 * math.Pow(t+kelvin, 4) * (0.34 - 0.14*math.Sqrt(ea)) * (1.35*math.Min(ratio, 1) - 0.35)
+	rn := 0.77*rs - rnl
+
+	g := 0.5 * rn
+	if day {
+		g = 0.1 * rn
+	}
+
+	delta := slope(t)
+
+	return (0.408*delta*(rn-g) + gamma*37/(t+kelvin)*u2*(saturationVapourPressure(t)-ea)) /
+		(delta + gamma*(1+0.34*u2))
+}
+
+// penmanMonteithDaily returns the daily reference evapotranspiration in mm by
+// FAO-56 equation 6, from the maximum and minimum temperatures in °C, the
+// actual vapour pressure in kPa, the wind speed at 2m in m/s, the solar and
+// clear sky radiation in MJ/m²/day and the pressure in kPa.
+func penmanMonteithDaily(tMax, tMin, ea, u2, rs, rso, pressure float64) float64 {
+	gamma := 0.665e-3 * pressure
+	t := (tMax + tMin) / 2
+	es := (saturationVapourPressure(tMax) + saturationVapourPressure(tMin)) / 2
+
+	rnl := 4.903e-9 * (math.Pow(tMax+kelvin, 4) + math.Pow(tMin+kelvin, 4)) / 2 *
+		(0.34 - 0.14*math.Sqrt(ea)) * (1.35*math.Min(rs/rso, 1) - 0.35)
+	rn := 0.77*rs - rnl
+
+	delta := slope(t)
+
+	return (0.408*delta*rn + gamma*900/(t+kelvin)*u2*(es-ea)) /
+		(delta + gamma*(1+0.34*u2))
+}
+
+// extraterrestrialRadiation returns the daily extraterrestrial radiation in
+// MJ/m²/day at the latitude on the day t, FAO-56 equation 21.
+func extraterrestrialRadiation(t time.Time, latitude float64) float64 {
+	j := float64(t.UTC().YearDay())
+	phi := latitude * deg2rad
+
+	dr := 1 + 0.033*math.Cos(2*math.Pi*j/365)
+	decl := 0.409 * math.Sin(2*math.Pi*j/365-1.39)
+	ws := math.Acos(math.Max(-1, math.Min(1, -math.Tan(phi)*math.Tan(decl))))
+
+	return 24 * 60 / math.Pi * solarConstantMJ * dr *
+		(ws*math.Sin(phi)*math.Sin(decl) + math.Cos(phi)*math.Cos(decl)*math.Sin(ws))
+}
+
+// hourlyET0 returns the reference evapotranspiration of a forecast hour at a
+// location, with the solar radiation estimated from the cloud cover.
+func hourlyET0(location Location, hour Forecast) (float64, bool) {
+	c := hour.Conditions
+
+	t, ok := c["temp"]
+	if !ok {
+		return 0, false
+	}
+
+	wind, ok := c["wind_speed"]
+	if !ok {
+		return 0, false
+	}
+
+	ea, ok := actualVapourPressure(c, t, t)
+	if !ok {
+		return 0, false
+	}
+
+	clouds, ok := c["clouds"]
+	if !ok {
+		clouds = 50
+	}
+
+	at := time.Unix(int64(hour.Dt), 0)
+	elevation, _ := sunPosition(at, location.Latitude, location.Longitude)
+	rs := estimateIrradiance(at, location.Latitude, location.Longitude, clouds).GHI * wattsToMJPerHour
+
+	et0 := penmanMonteithHourly(t, ea, wind*windHeightFactor, rs, cloudRadiationRatio(clouds), atmosphericPressure(location.Altitude), elevation > 0)
+
+	return math.Max(0, et0), true
+}
+
+// dailyET0 returns the reference evapotranspiration of a forecast day at a
+// location.  Solar radiation is estimated from the cloud cover, or from the
+// temperature range by Hargreaves when it is not forecast.
+func dailyET0(location Location, day Forecast) (float64, bool) {
+	c := day.Conditions
+
+	tMax, ok := c["temp_max"]
+	if !ok {
+		return 0, false
+	}
+
+	tMin, ok := c["temp_min"]
+	if !ok {
+		return 0, false
+	}
+
+	wind, ok := c["wind_speed"]
+	if !ok {
+		return 0, false
+	}
+
+	ea, ok := actualVapourPressure(c, tMax, tMin)
+	if !ok {
+		// FAO-56 suggests the minimum temperature as the dew point.
+		ea = saturationVapourPressure(tMin)
+	}
+
+	ra := extraterrestrialRadiation(time.Unix(int64(day.Dt), 0), location.Latitude)
+	rso := (0.75 + 2e-5*location.Altitude) * ra
+
+	// There is no sun during polar night.
+	if rso <= 0 {
+		return 0, false
+	}
+
+	var rs float64
+	if clouds, ok := c["clouds"]; ok {
+		rs = rso * cloudRadiationRatio(clouds)
+	} else {
+		rs = math.Min(0.16*math.Sqrt(math.Max(tMax-tMin, 0))*ra, rso)
+	}
+
+	et0 := penmanMonteithDaily(tMax, tMin, ea, wind*windHeightFactor, rs, rso, atmosphericPressure(location.Altitude))
+
+	return math.Max(0, et0), true
+}
+
+// actualVapourPressure returns the vapour pressure in kPa of forecast
+// conditions from the dew point, or from the humidity and the saturation
+// vapour pressure of the temperatures.
+func actualVapourPressure(c map[string]float64, tMax, tMin float64) (float64, bool) {
+	if dewPoint, ok := c["dew_point"]; ok {
+		return saturationVapourPressure(dewPoint), true
+	}
+
+	if rh, ok := c["humidity"]; ok {
+		es := (saturationVapourPressure(tMax) + saturationVapourPressure(tMin)) / 2
+		return rh / 100 * es, true
+	}
+
+	return 0, false
+}
+
+// addET0 adds the reference evapotranspiration as the et0 condition of the
+// hourly and daily forecasts of a location.
+func addET0(location Location, weather *Weather) {
+	for _, hour := range weather.Hourly {
+		if et0, ok := hourlyET0(location, hour); ok {
+			hour.Conditions["et0"] = et0
+		}
+	}
+
+	for _, day := range weather.Daily {
+		if et0, ok := dailyET0(location, day); ok {
+			day.Conditions["et0"] = et0
+		}
+	}
+}
+
+// hourlyWaterDeficit sums the reference evapotranspiration and rain of the
+// hourly forecasts into days at the local time zone, for providers without a
+// daily forecast.  Each forecast holds until the next, and only the days the
+// forecasts cover in full are returned.
+func hourlyWaterDeficit(hours []Forecast, tz *time.Location) []Forecast {
+	sorted := make([]Forecast, len(hours))
+	copy(sorted, hours)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Dt < sorted[j].Dt })
+
+	type daySum struct {
+		noon    time.Time
+		et0     float64
+		rain    float64
+		covered time.Duration
+	}
+
+	var days []*daySum
+	step := time.Hour
+	for i, hour := range sorted {
+		start := time.Unix(int64(hour.Dt), 0).In(tz)
+		if i+1 < len(sorted) {
+			step = time.Unix(int64(sorted[i+1].Dt), 0).Sub(start)
+		}
+
+		et0, ok := hour.Conditions["et0"]
+		if !ok {
+			continue
+		}
+
+		noon := time.Date(start.Year(), start.Month(), start.Day(), 12, 0, 0, 0, tz)
+		if len(days) == 0 || !days[len(days)-1].noon.Equal(noon) {
+			days = append(days, &daySum{noon: noon})
+		}
+		d := days[len(days)-1]
+
+		// The free forecast gives the rain over each 3 hours.
+		rain := hour.Conditions["rain_1h"] * step.Hours()
+		if v, ok := hour.Conditions["rain_3h"]; ok && step > time.Hour {
+			rain = v * step.Hours() / 3
+		}
+
+		d.et0 += et0 * step.Hours()
+		d.rain += rain
+		d.covered += step
+	}
+
+	forecasts := make([]Forecast, 0, len(days))
+	for _, d := range days {
+		if d.covered < 24*time.Hour {
+			continue
+		}
+
+		forecasts = append(forecasts, Forecast{
+			Dt:         int(d.noon.Unix()),
+			Conditions: map[string]float64{"et0": d.et0, "rain": d.rain},
+		})
+	}
+
+	return forecasts
+}
+
+// emitWaterDeficit sends the forecast reference evapotranspiration less the
+// forecast rain of each day, from the hourly forecast when there is no daily
+// forecast.
+func (o *OWM) emitWaterDeficit(ch chan<- prometheus.Metric, provider string, location Location, weather *Weather) {
+	days := weather.Daily
+	if len(days) == 0 {
+		days = hourlyWaterDeficit(weather.Hourly, weather.location())
+	}
+
+	for _, day := range days {
+		et0, ok := day.Conditions["et0"]
+		if !ok {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			metricWaterDeficitDesc,
+			prometheus.GaugeValue,
+			et0-day.Conditions["rain"],
+			location.Name,
+			provider,
+			futureDays(day.Dt),
+		)
+	}
+}
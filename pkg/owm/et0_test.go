@@ -0,0 +1,103 @@
+package owm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPenmanMonteithDaily(t *testing.T) {
+	// FAO-56 example 18, Brussels on 6 July.
+	ra := 41.09
+	rso := (0.75 + 2e-5*100) * ra
+
+	et0 := penmanMonteithDaily(21.5, 12.3, 1.409, 2.078, 22.07, rso, atmosphericPressure(100))
+	require.InDelta(t, 3.9, et0, 0.05)
+}
+
+func TestPenmanMonteithHourly(t *testing.T) {
+	// FAO-56 example 19, N'Diaye on 1 October.
+	pressure := atmosphericPressure(8)
+
+	day := penmanMonteithHourly(38, 0.52*saturationVapourPressure(38), 3.3, 2.450, 2.450/3.26, pressure, true)
+	require.InDelta(t, 0.63, day, 0.01)
+
+	night := penmanMonteithHourly(28, 0.90*saturationVapourPressure(28), 1.9, 0, 0.8, pressure, false)
+	require.InDelta(t, 0.0, night, 0.01)
+}
+
+func TestExtraterrestrialRadiation(t *testing.T) {
+	// FAO-56 example 8, 20°S on 3 September.
+	ra := extraterrestrialRadiation(time.Date(2021, time.September, 3, 12, 0, 0, 0, time.UTC), -20)
+	require.InDelta(t, 32.2, ra, 0.1)
+
+	// There is no sun during polar night.
+	ra = extraterrestrialRadiation(time.Date(2021, time.December, 21, 12, 0, 0, 0, time.UTC), 80)
+	require.InDelta(t, 0, ra, 1e-9)
+}
+
+func TestAddET0(t *testing.T) {
+	location := Location{Name: "home", Latitude: 50.8, Longitude: 4.35, Altitude: 100}
+	noon := time.Date(2021, time.July, 6, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2021, time.July, 6, 0, 0, 0, 0, time.UTC)
+
+	weather := &Weather{
+		Hourly: []Forecast{
+			{Dt: int(noon.Unix()), Conditions: map[string]float64{"temp": 21, "humidity": 60, "wind_speed": 3, "clouds": 20}},
+			{Dt: int(midnight.Unix()), Conditions: map[string]float64{"temp": 13, "humidity": 90, "wind_speed": 1, "clouds": 20}},
+			// Without humidity or dew point there is no estimate.
+			{Dt: int(noon.Unix()), Conditions: map[string]float64{"temp": 21, "wind_speed": 3}},
+		},
+		Daily: []Forecast{
+			{Dt: int(noon.Unix()), Conditions: map[string]float64{"temp_max": 21.5, "temp_min": 12.3, "dew_point": 12, "wind_speed": 2.8, "clouds": 30}},
+			// Radiation is estimated from the temperature range without clouds.
+			{Dt: int(noon.Unix()), Conditions: map[string]float64{"temp_max": 21.5, "temp_min": 12.3, "wind_speed": 2.8}},
+		},
+	}
+
+	addET0(location, weather)
+
+	require.Greater(t, weather.Hourly[0].Conditions["et0"], 0.3)
+	require.Less(t, weather.Hourly[1].Conditions["et0"], 0.05)
+	require.NotContains(t, weather.Hourly[2].Conditions, "et0")
+
+	for _, day := range weather.Daily {
+		require.InDelta(t, 4, day.Conditions["et0"], 1.5)
+	}
+}
+
+func TestHourlyWaterDeficit(t *testing.T) {
+	tz := time.FixedZone("CEST", 2*3600)
+	start := time.Date(2021, time.July, 6, 18, 0, 0, 0, tz)
+
+	// Hourly forecasts from the evening of one day to the morning of the
+	// day after next.
+	var hours []Forecast
+	for h := 0; h < 40; h++ {
+		hours = append(hours, Forecast{
+			Dt:         int(start.Add(time.Duration(h) * time.Hour).Unix()),
+			Conditions: map[string]float64{"et0": 0.2, "rain_1h": 0.1},
+		})
+	}
+
+	days := hourlyWaterDeficit(hours, tz)
+	require.Len(t, days, 1)
+	require.Equal(t, int(time.Date(2021, time.July, 7, 12, 0, 0, 0, tz).Unix()), days[0].Dt)
+	require.InDelta(t, 4.8, days[0].Conditions["et0"], 1e-9)
+	require.InDelta(t, 2.4, days[0].Conditions["rain"], 1e-9)
+
+	// The free forecast gives 3 hourly steps, with the rain over each.
+	hours = hours[:0]
+	for h := 0; h < 40; h += 3 {
+		hours = append(hours, Forecast{
+			Dt:         int(start.Add(time.Duration(h) * time.Hour).Unix()),
+			Conditions: map[string]float64{"et0": 0.2, "rain_3h": 0.6},
+		})
+	}
+
+	days = hourlyWaterDeficit(hours, tz)
+	require.Len(t, days, 1)
+	require.InDelta(t, 4.8, days[0].Conditions["et0"], 1e-9)
+	require.InDelta(t, 4.8, days[0].Conditions["rain"], 1e-9)
+}
@@ -3,11 +3,8 @@ package owm
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
-	owm "github.com/briandowns/openweathermap"
-
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -19,44 +16,101 @@ var (
 	metricWeatherForecastConditionsDesc = prometheus.NewDesc(
 		"weather_forecast",
 		"Weather condition forecast",
-		[]string{"location", "condition", "future_hours"},
+		[]string{"location", "provider", "condition", "future_hours"},
+		nil,
+	)
+
+	metricWeatherForecastDailyDesc = prometheus.NewDesc(
+		"weather_forecast_daily",
+		"Weather condition daily forecast",
+		[]string{"location", "provider", "condition", "future_days"},
 		nil,
 	)
 
 	metricWeatherCurrentConditionsDesc = prometheus.NewDesc(
 		"weather_current",
 		"Weather condition current",
-		[]string{"location", "condition"},
+		[]string{"location", "provider", "condition"},
 		nil,
 	)
 
 	metricPollutionCurrentDesc = prometheus.NewDesc(
 		"pollution_current_aqi",
 		"Current Air Pollution (AQI)",
-		[]string{"location"},
+		[]string{"location", "provider"},
+		nil,
+	)
+
+	metricPollutionComponentsDesc = prometheus.NewDesc(
+		"pollution_current",
+		"Current concentration of air pollutants and pollen",
+		[]string{"location", "provider", "component"},
 		nil,
 	)
 
 	metricWeatherEpochDesc = prometheus.NewDesc(
 		"weather_epoch",
 		"Weather event: (sunrise|sunset|moonrise|moonset)",
-		[]string{"location", "event"},
+		[]string{"location", "provider", "event"},
 		nil,
 	)
 
 	metricWeatherSummaryDesc = prometheus.NewDesc(
 		"weather_summary",
 		"Weather description",
-		[]string{"location", "main", "description"},
+		[]string{"location", "provider", "main", "description"},
 		nil,
 	)
 )
 
 func (o *OWM) Describe(ch chan<- *prometheus.Desc) {
 	ch <- metricWeatherForecastConditionsDesc
+	ch <- metricWeatherForecastDailyDesc
 	ch <- metricWeatherCurrentConditionsDesc
 	ch <- metricWeatherEpochDesc
 	ch <- metricPollutionCurrentDesc
+	ch <- metricPollutionComponentsDesc
+	ch <- metricWeatherSummaryDesc
+	ch <- metricWeatherLongRangeDesc
+	ch <- metricSolarIrradianceDesc
+	ch <- metricSolarPVPowerDesc
+	ch <- metricSoilTemperatureDesc
+	ch <- metricSoilMoistureDesc
+	ch <- metricWeatherObservedDesc
+	ch <- metricForecastErrorMAEDesc
+	ch <- metricForecastErrorBiasDesc
+	ch <- metricForecastErrorSamplesDesc
+	ch <- metricWeatherForecastCorrectedDesc
+	ch <- metricForecastCorrectionOffsetDesc
+	ch <- metricWeatherAlertDesc
+	ch <- metricWeatherForecastEnsembleDesc
+	ch <- metricDegreeDaysDesc
+	ch <- metricForecastDegreeHoursDesc
+	ch <- metricGrowingDegreeDaysDesc
+	ch <- metricFrostRiskDesc
+	ch <- metricFrostHoursDesc
+	ch <- metricFireWeatherIndexDesc
+	ch <- metricWBGTCategoryDesc
+	ch <- metricForecastWBGTCategoryDesc
+	ch <- metricPressureChangeDesc
+	ch <- metricPressureTendencyDesc
+	ch <- metricPressureRapidDropDesc
+	ch <- metricPrecipitationTotalDesc
+	ch <- metricPrecipitationTodayDesc
+	ch <- metricPrecipitation24hDesc
+	ch <- metricForecastWindowDesc
+	ch <- metricSunElevationDesc
+	ch <- metricSunAzimuthDesc
+	ch <- metricSunEventDesc
+	ch <- metricSunDayLengthDesc
+	ch <- metricSunNextEventDesc
+	ch <- metricMoonEventDesc
+	ch <- metricMoonPhaseDesc
+	ch <- metricMoonIlluminationDesc
+	ch <- metricWaterDeficitDesc
+
+	o.stationUploads.Describe(ch)
+	o.stationLastUpload.Describe(ch)
 }
 
 func (o *OWM) Collect(ch chan<- prometheus.Metric) {
@@ -66,182 +120,298 @@ func (o *OWM) Collect(ch chan<- prometheus.Metric) {
 	ctx, span := o.tracer.Start(ctx, "Collect")
 	defer span.End()
 
+	o.stationUploads.Collect(ch)
+	o.stationLastUpload.Collect(ch)
+
 	_ = level.Debug(o.logger).Log("msg", "collecting openweathermap data",
 		"traceID", trace.SpanContextFromContext(ctx).TraceID().String(),
 	)
 
+	var grouped []Location
+	now := time.Now()
+
 	for _, location := range o.cfg.Locations {
-		o.collectPollution(ctx, ch, location)
-		o.collectOne(ctx, ch, location)
+		// Group locations are those given by city ID alone.
+		if location.CityID > 0 && location.Latitude == 0 && location.Longitude == 0 {
+			grouped = append(grouped, location)
+			continue
+		}
+
+		o.collectAstronomy(ch, location, now)
+
+		var forecasts [][]Forecast
+		for _, name := range location.providers() {
+			if weather := o.collectProvider(ctx, ch, o.providers[name], location); weather != nil {
+				forecasts = append(forecasts, weather.Hourly)
+			}
+		}
+
+		if len(forecasts) > 1 {
+			o.emitEnsemble(ch, location, forecasts)
+		}
+
+		// The long range forecast is read from OpenWeatherMap only.
+		if o.cfg.ForecastDays > 0 && location.hasProvider(ProviderOpenWeatherMap) {
+			o.collectLongRange(ctx, ch, location)
+		}
+	}
+
+	if len(grouped) > 0 {
+		o.collectGroup(ctx, ch, grouped)
+	}
+
+	if len(o.cfg.AlertFeeds) > 0 {
+		o.collectAlertFeeds(ctx, ch)
+	}
+
+	if len(o.cfg.Polygons) > 0 {
+		o.collectSoil(ctx, ch)
+	}
+
+	o.collectObserved(ch)
+
+	if o.cfg.ForecastAccuracy {
+		o.collectAccuracy(ch)
+	}
+
+	if o.cfg.ForecastCorrection {
+		o.collectCorrection(ch)
 	}
 }
 
-func (o *OWM) collectPollution(ctx context.Context, ch chan<- prometheus.Metric, location Location) {
-	_, span := o.tracer.Start(ctx, "collectPollution")
+// collectProvider collects the air quality, current conditions and forecasts
+// for a location from a provider, returning the weather when it was read.
+func (o *OWM) collectProvider(ctx context.Context, ch chan<- prometheus.Metric, p Provider, location Location) *Weather {
+	ctx, span := o.tracer.Start(ctx, "collectProvider")
 	defer span.End()
 
-	coord := &owm.Coordinates{
-		Longitude: location.Longitude,
-		Latitude:  location.Latitude,
+	aq, err := p.AirQuality(ctx, location)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		_ = level.Error(o.logger).Log("msg", "air quality failed", "provider", p.Name(), "location", location.Name, "err", err)
+	} else if aq != nil {
+		o.emitAirQuality(ch, p.Name(), location, aq)
 	}
 
-	pollution, err := owm.NewPollution(o.cfg.APIKey, owm.WithHttpClient(o.client))
+	weather, err := p.Weather(ctx, location)
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
-		_ = level.Error(o.logger).Log("msg", "failed to get new pollution data", "err", err)
+		_ = level.Error(o.logger).Log("msg", "weather failed", "provider", p.Name(), "location", location.Name, "err", err)
+		return nil
 	}
 
-	params := &owm.PollutionParameters{
-		Location: *coord,
-		Datetime: "current",
+	o.emitWeather(ctx, ch, p.Name(), location, weather)
+
+	return weather
+}
+
+// emitWeather sends the current conditions and forecasts from a provider for a
+// location, along with the metrics derived from them.
+func (o *OWM) emitWeather(ctx context.Context, ch chan<- prometheus.Metric, provider string, location Location, weather *Weather) {
+	if c := weather.Current; c != nil {
+		addComfort(c.Conditions)
+		if o.cfg.WBGT {
+			addWBGT(location, c.Dt, c.Conditions)
+		}
+
+		// Sunrise and sunset
+		o.emitEpochs(ch, provider, location, map[string]float64{
+			"sunrise": float64(c.Sunrise),
+			"sunset":  float64(c.Sunset),
+		})
+
+		o.emitCurrent(ch, provider, location, c.Dt, c.Conditions)
+
+		for _, summary := range c.Summary {
+			o.weatherSummary(ctx, ch, provider, location, summary)
+		}
 	}
 
-	if err := pollution.PollutionByParams(params); err != nil {
-		span.SetStatus(codes.Error, err.Error())
-		_ = level.Error(o.logger).Log("msg", "failed to update pollution data", "err", err)
+	for _, hour := range weather.Hourly {
+		addComfort(hour.Conditions)
+		if o.cfg.WBGT {
+			addWBGT(location, hour.Dt, hour.Conditions)
+		}
 	}
 
-	for _, p := range pollution.List {
-		ch <- prometheus.MustNewConstMetric(
-			metricPollutionCurrentDesc,
-			prometheus.GaugeValue,
-			p.Main.Aqi,
-			location.Name,
-		)
+	if o.cfg.WBGT {
+		o.emitWBGT(ch, provider, location, weather)
 	}
-}
 
-func (o *OWM) collectOne(ctx context.Context, ch chan<- prometheus.Metric, location Location) {
-	ctx, span := o.tracer.Start(ctx, "collectOne")
-	defer span.End()
+	if o.cfg.Evapotranspiration {
+		addET0(location, weather)
+		o.emitWaterDeficit(ch, provider, location, weather)
+	}
 
-	coord := &owm.Coordinates{
-		Longitude: location.Longitude,
-		Latitude:  location.Latitude,
+	if len(weather.Hourly) > 0 {
+		o.emitForecasts(ctx, ch, provider, location, weather.Hourly)
 	}
 
-	// Possibility to exclude information. For example exclude daily information []string{ExcludeDaily}
-	w, err := owm.NewOneCall("C", "EN", o.cfg.APIKey, []string{})
-	if err != nil {
-		level.Error(o.logger).Log("msg", "onecall failed", "err", err)
-		return
+	if len(o.cfg.ForecastWindows) > 0 {
+		o.emitForecastWindows(ch, provider, location, weather.Hourly)
 	}
 
-	err = w.OneCallByCoordinates(coord)
-	if err != nil {
-		level.Error(o.logger).Log("msg", "onecall coordinates failed", "err", err)
+	if o.cfg.DegreeDays {
+		o.emitDegreeDays(ch, provider, location, weather)
 	}
 
-	// Sunrise and sunset
-	epochs := map[string]float64{
-		"sunrise": float64(w.Current.Sunrise),
-		"sunset":  float64(w.Current.Sunset),
+	if o.cfg.PrecipitationTotals {
+		o.emitPrecipitation(ch, provider, location, weather)
 	}
 
-	for epoch, value := range epochs {
-		ch <- prometheus.MustNewConstMetric(
-			metricWeatherEpochDesc,
-			prometheus.CounterValue,
-			value,
-			location.Name,
-			epoch,
-		)
+	if o.cfg.PressureTendency {
+		o.emitPressureTendency(ch, provider, location, weather)
 	}
 
-	// Current conditions
-	currentConditions := map[string]float64{
-		"clouds":      float64(w.Current.Clouds),
-		"dew_point":   w.Current.DewPoint,
-		"feels_like":  w.Current.FeelsLike,
-		"humidity":    float64(w.Current.Humidity),
-		"pressure":    float64(w.Current.Pressure),
-		"rain_1h":     w.Current.Rain.OneH,
-		"rain_3h":     w.Current.Rain.ThreeH,
-		"snow_1h":     w.Current.Snow.OneH,
-		"snow_3h":     w.Current.Snow.ThreeH,
-		"temp":        w.Current.Temp,
-		"uvi":         float64(w.Current.UVI),
-		"visibility":  float64(w.Current.Visibility),
-		"wind_degree": w.Current.WindDeg,
-		"wind_gust":   w.Current.WindGust,
-		"wind_speed":  w.Current.WindSpeed,
-	}
-
-	for condition, value := range currentConditions {
-		if w.Current.Dt > 0 {
+	// Growing degree days are accumulated from the first provider only.
+	if location.GDD != nil && provider == location.providers()[0] {
+		o.emitGrowingDegreeDays(ch, location, weather)
+	}
+
+	// As is the Fire Weather Index.
+	if location.FWI && provider == location.providers()[0] {
+		o.emitFireWeatherIndex(ch, location, weather)
+	}
+
+	if location.Frost {
+		o.emitFrost(ch, provider, location, weather)
+	}
+
+	if len(weather.Moon) > 0 {
+		o.emitMoon(ch, provider, location, weather.Moon)
+	}
+
+	if len(weather.Alerts) > 0 {
+		o.emitAlerts(ch, provider, location, weather.Alerts)
+	}
+
+	for _, day := range weather.Daily {
+		for condition, value := range day.Conditions {
 			ch <- prometheus.MustNewConstMetric(
-				metricWeatherCurrentConditionsDesc,
+				metricWeatherForecastDailyDesc,
 				prometheus.GaugeValue,
 				value,
 				location.Name,
+				provider,
 				condition,
+				futureDays(day.Dt),
 			)
 		}
 	}
+}
 
-	for _, weather := range w.Current.Weather {
-		if w.Current.Dt > 0 {
-			o.weatherSummary(ctx, ch, location, weather)
-		}
+// emitAirQuality sends the air quality from a provider for a location.
+func (o *OWM) emitAirQuality(ch chan<- prometheus.Metric, provider string, location Location, aq *AirQuality) {
+	ch <- prometheus.MustNewConstMetric(
+		metricPollutionCurrentDesc,
+		prometheus.GaugeValue,
+		aq.AQI,
+		location.Name,
+		provider,
+	)
+
+	for component, value := range aq.Components {
+		ch <- prometheus.MustNewConstMetric(
+			metricPollutionComponentsDesc,
+			prometheus.GaugeValue,
+			value,
+			location.Name,
+			provider,
+			component,
+		)
 	}
+}
 
-	for _, hour := range w.Hourly {
-		hourlyConditions := map[string]float64{
-			"clouds":      float64(hour.Clouds),
-			"dew_point":   hour.DewPoint,
-			"feels_like":  hour.FeelsLike,
-			"humidity":    float64(hour.Humidity),
-			"pressure":    float64(hour.Pressure),
-			"rain_1h":     hour.Rain.OneH,
-			"rain_3h":     hour.Rain.ThreeH,
-			"snow_1h":     hour.Snow.OneH,
-			"snow_3h":     hour.Snow.ThreeH,
-			"temp":        hour.Temp,
-			"uvi":         float64(hour.UVI),
-			"visibility":  float64(hour.Visibility),
-			"wind_degree": hour.WindDeg,
-			"wind_gust":   hour.WindGust,
-			"wind_speed":  hour.WindSpeed,
+// emitEpochs sends the event timestamps for a location, skipping those the
+// provider did not give.
+func (o *OWM) emitEpochs(ch chan<- prometheus.Metric, provider string, location Location, epochs map[string]float64) {
+	for epoch, value := range epochs {
+		if value == 0 {
+			continue
 		}
 
-		if hour.Dt > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			metricWeatherEpochDesc,
+			prometheus.GaugeValue,
+			value,
+			location.Name,
+			provider,
+			epoch,
+		)
+	}
+}
 
-			i, err := strconv.ParseInt(strconv.Itoa(hour.Dt), 10, 64)
-			if err != nil {
-				_ = level.Error(o.logger).Log("failed to parse", "int", fmt.Sprintf("%d", hour.Dt))
-				continue
-			}
+// emitCurrent sends the current conditions for a location, observed at the
+// unix time dt.
+func (o *OWM) emitCurrent(ch chan<- prometheus.Metric, provider string, location Location, dt int, conditions map[string]float64) {
+	for condition, value := range conditions {
+		ch <- prometheus.MustNewConstMetric(
+			metricWeatherCurrentConditionsDesc,
+			prometheus.GaugeValue,
+			value,
+			location.Name,
+			provider,
+			condition,
+		)
+	}
 
-			tm := time.Until(time.Unix(i, 0)).Round(1 * time.Hour).Hours()
-
-			for condition, value := range hourlyConditions {
-				ch <- prometheus.MustNewConstMetric(
-					metricWeatherForecastConditionsDesc,
-					prometheus.GaugeValue,
-					value,
-					location.Name,
-					condition,
-					fmt.Sprintf("%dh", int(tm)),
-				)
-			}
+	if o.accuracy != nil {
+		o.scoreForecasts(provider, location, time.Unix(int64(dt), 0), conditions)
+	}
+}
+
+// emitForecasts sends the forecast conditions for a location, along with the
+// metrics derived from them.
+func (o *OWM) emitForecasts(ctx context.Context, ch chan<- prometheus.Metric, provider string, location Location, hours []Forecast) {
+	for _, hour := range hours {
+		o.emitForecast(ch, provider, location, hour.Dt, hour.Conditions)
+	}
+
+	if o.accuracy != nil {
+		now := time.Now()
+
+		if o.cfg.ForecastCorrection {
+			o.emitCorrected(ch, provider, location, now, hours)
 		}
 
-		// for _, weather := range hour.Weather {
-		// 	if hour.Dt > 0 {
-		// 		o.weatherSummary(ctx, ch, location, weather)
-		// 	}
-		// }
+		o.accuracy.record(provider, location.Name, now, hours)
+	}
+
+	if location.PV != nil {
+		o.collectSolar(ctx, ch, provider, location, hours)
+	}
+}
 
+// emitForecast sends the forecast conditions for a location at the unix time
+// dt, labelled with the number of hours from now.
+func (o *OWM) emitForecast(ch chan<- prometheus.Metric, provider string, location Location, dt int, conditions map[string]float64) {
+	for condition, value := range conditions {
+		ch <- prometheus.MustNewConstMetric(
+			metricWeatherForecastConditionsDesc,
+			prometheus.GaugeValue,
+			value,
+			location.Name,
+			provider,
+			condition,
+			futureHours(dt),
+		)
 	}
+}
+
+// futureHours returns the future_hours label value for the unix time dt.
+func futureHours(dt int) string {
+	tm := time.Until(time.Unix(int64(dt), 0)).Round(1 * time.Hour).Hours()
 
+	return fmt.Sprintf("%dh", int(tm))
 }
 
-func (o *OWM) weatherSummary(ctx context.Context, ch chan<- prometheus.Metric, location Location, summary owm.Weather) {
+func (o *OWM) weatherSummary(ctx context.Context, ch chan<- prometheus.Metric, provider string, location Location, summary Summary) {
 	ch <- prometheus.MustNewConstMetric(
 		metricWeatherSummaryDesc,
 		prometheus.CounterValue,
 		1,
 		location.Name,
+		provider,
 		summary.Main,
 		summary.Description,
 	)
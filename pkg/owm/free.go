@@ -0,0 +1,127 @@
+package owm
+
+import (
+	"context"
+
+	owm "github.com/briandowns/openweathermap"
+
+	"github.com/go-kit/log/level"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// forecast5Count is the number of 3 hour steps returned by the 5 day forecast.
+const forecast5Count = 40
+
+// free reads the current conditions and forecast for a location using the
+// Current Weather and 5 day/3 hour forecast APIs, which are available to free
+// API keys.
+func (p *owmProvider) free(ctx context.Context, location Location) (*Weather, error) {
+	_, span := p.o.tracer.Start(ctx, "collectFree")
+	defer span.End()
+
+	coord := &owm.Coordinates{
+		Longitude: location.Longitude,
+		Latitude:  location.Latitude,
+	}
+
+	weather := &Weather{}
+
+	current, currentErr := p.freeCurrent(coord)
+	if currentErr != nil {
+		span.SetStatus(codes.Error, currentErr.Error())
+		_ = level.Error(p.o.logger).Log("msg", "current weather failed", "location", location.Name, "err", currentErr)
+	}
+	weather.Current = current
+
+	hourly, forecastErr := p.freeForecast(coord)
+	if forecastErr != nil {
+		span.SetStatus(codes.Error, forecastErr.Error())
+		_ = level.Error(p.o.logger).Log("msg", "5 day forecast failed", "location", location.Name, "err", forecastErr)
+	}
+	weather.Hourly = hourly
+
+	if currentErr != nil && forecastErr != nil {
+		return nil, currentErr
+	}
+
+	return weather, nil
+}
+
+func (p *owmProvider) freeCurrent(coord *owm.Coordinates) (*Current, error) {
+	w, err := owm.NewCurrent("C", "EN", p.o.cfg.APIKey, owm.WithHttpClient(p.o.client))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = w.CurrentByCoordinates(coord); err != nil {
+		return nil, err
+	}
+
+	return currentWeather(w), nil
+}
+
+// currentWeather converts the conditions reported by the Current Weather API.
+func currentWeather(w *owm.CurrentWeatherData) *Current {
+	if w.Dt == 0 {
+		return nil
+	}
+
+	return &Current{
+		Dt:      w.Dt,
+		Sunrise: w.Sys.Sunrise,
+		Sunset:  w.Sys.Sunset,
+		Conditions: map[string]float64{
+			"clouds":      float64(w.Clouds.All),
+			"feels_like":  w.Main.FeelsLike,
+			"humidity":    float64(w.Main.Humidity),
+			"pressure":    w.Main.Pressure,
+			"rain_1h":     w.Rain.OneH,
+			"rain_3h":     w.Rain.ThreeH,
+			"snow_1h":     w.Snow.OneH,
+			"snow_3h":     w.Snow.ThreeH,
+			"temp":        w.Main.Temp,
+			"visibility":  float64(w.Visibility),
+			"wind_degree": w.Wind.Deg,
+			"wind_speed":  w.Wind.Speed,
+		},
+		Summary: summaries(w.Weather),
+	}
+}
+
+func (p *owmProvider) freeForecast(coord *owm.Coordinates) ([]Forecast, error) {
+	f, err := owm.NewForecast("5", "C", "EN", p.o.cfg.APIKey, owm.WithHttpClient(p.o.client))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = f.DailyByCoordinates(coord, forecast5Count); err != nil {
+		return nil, err
+	}
+
+	data, ok := f.ForecastWeatherJson.(*owm.Forecast5WeatherData)
+	if !ok {
+		return nil, nil
+	}
+
+	hours := make([]Forecast, 0, len(data.List))
+	for _, step := range data.List {
+		if step.Dt == 0 {
+			continue
+		}
+
+		hours = append(hours, Forecast{Dt: step.Dt, Conditions: map[string]float64{
+			"clouds":      float64(step.Clouds.All),
+			"feels_like":  step.Main.FeelsLike,
+			"humidity":    float64(step.Main.Humidity),
+			"pressure":    step.Main.Pressure,
+			"rain_3h":     step.Rain.ThreeH,
+			"snow_3h":     step.Snow.ThreeH,
+			"temp":        step.Main.Temp,
+			"wind_degree": step.Wind.Deg,
+			"wind_speed":  step.Wind.Speed,
+		}})
+	}
+
+	return hours, nil
+}
@@ -0,0 +1,145 @@
+package owm
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// frostRiskAhead is how far ahead the frost risk looks.
+	frostRiskAhead = 24 * time.Hour
+
+	// radiativeCooling is how far in °C exposed surfaces may cool below the
+	// air temperature on a clear, still night.
+	radiativeCooling = 3.0
+)
+
+var (
+	metricFrostRiskDesc = prometheus.NewDesc(
+		"weather_frost_risk",
+		"Risk of frost over the next 24 hours, from 0 to 1",
+		[]string{"location", "provider"},
+		nil,
+	)
+
+	metricFrostHoursDesc = prometheus.NewDesc(
+		"weather_frost_hours",
+		"Hours until exposed surfaces are forecast to fall to 0°C",
+		[]string{"location", "provider"},
+		nil,
+	)
+)
+
+// surfaceTemperature estimates the temperature of exposed surfaces, eg:
+// leaves, for an air temperature and the forecast conditions.  Clear skies let
+// surfaces cool below the air temperature, which condensation halts at about
+// the dew point.
+func surfaceTemperature(temp float64, conditions map[string]float64) float64 {
+	clouds, ok := conditions["clouds"]
+	if !ok {
+		clouds = 50
+	}
+
+	cooling := radiativeCooling * (1 - clouds/100)
+	if dewPoint, ok := conditions["dew_point"]; ok {
+		cooling = math.Min(cooling, math.Max(temp-dewPoint, 0))
+	}
+
+	return temp - cooling
+}
+
+// frostRisk returns the risk of frost over the 24 hours from now, and the
+// hours until frost is forecast, if it is.  The hourly forecast is used where
+// it reaches, and the daily minima beyond.  The risk rises from 0 at a
+// surface temperature of 2°C to 1 at -2°C.
+func frostRisk(now time.Time, hours, days []Forecast) (float64, float64, bool) {
+	lowest := math.Inf(1)
+	frostAt := time.Time{}
+
+	var covered time.Time
+	for _, h := range hours {
+		temp, ok := h.Conditions["temp"]
+		if !ok {
+			continue
+		}
+
+		t := time.Unix(int64(h.Dt), 0)
+		if t.After(covered) {
+			covered = t
+		}
+
+		if t.Before(now.Add(-time.Hour)) {
+			continue
+		}
+
+		surface := surfaceTemperature(temp, h.Conditions)
+		if t.Before(now.Add(frostRiskAhead)) {
+			lowest = math.Min(lowest, surface)
+		}
+
+		if surface <= 0 && (frostAt.IsZero() || t.Before(frostAt)) {
+			frostAt = t
+		}
+	}
+
+	for _, d := range days {
+		temp, ok := d.Conditions["temp_min"]
+		if !ok {
+			continue
+		}
+
+		t := time.Unix(int64(d.Dt), 0)
+		if !t.After(covered) {
+			continue
+		}
+
+		surface := surfaceTemperature(temp, d.Conditions)
+		if t.Before(now.Add(frostRiskAhead)) {
+			lowest = math.Min(lowest, surface)
+		}
+
+		if surface <= 0 && frostAt.IsZero() {
+			frostAt = t
+		}
+	}
+
+	risk := 0.0
+	if !math.IsInf(lowest, 1) {
+		risk = math.Max(0, math.Min(1, (2-lowest)/4))
+	}
+
+	if frostAt.IsZero() {
+		return risk, 0, false
+	}
+
+	return risk, math.Max(0, frostAt.Sub(now).Hours()), true
+}
+
+// emitFrost sends the frost risk forecast for a location.
+func (o *OWM) emitFrost(ch chan<- prometheus.Metric, provider string, location Location, weather *Weather) {
+	if len(weather.Hourly) == 0 && len(weather.Daily) == 0 {
+		return
+	}
+
+	risk, hours, ok := frostRisk(time.Now(), weather.Hourly, weather.Daily)
+
+	ch <- prometheus.MustNewConstMetric(
+		metricFrostRiskDesc,
+		prometheus.GaugeValue,
+		risk,
+		location.Name,
+		provider,
+	)
+
+	if ok {
+		ch <- prometheus.MustNewConstMetric(
+			metricFrostHoursDesc,
+			prometheus.GaugeValue,
+			hours,
+			location.Name,
+			provider,
+		)
+	}
+}
@@ -0,0 +1,48 @@
+package owm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSurfaceTemperature(t *testing.T) {
+	// Clear and dry, surfaces cool well below the air.
+	require.Equal(t, -1.0, surfaceTemperature(2, map[string]float64{"clouds": 0, "dew_point": -5}))
+	// Overcast, surfaces stay at the air temperature.
+	require.Equal(t, 2.0, surfaceTemperature(2, map[string]float64{"clouds": 100, "dew_point": -5}))
+	// Humid, condensation stops the cooling at the dew point.
+	require.Equal(t, 1.5, surfaceTemperature(2, map[string]float64{"clouds": 0, "dew_point": 1.5}))
+}
+
+func TestFrostRisk(t *testing.T) {
+	now := time.Date(2022, 4, 20, 18, 0, 0, 0, time.UTC)
+
+	var hours []Forecast
+	for i, temp := range []float64{8, 6, 4, 3, 2, 2} {
+		hours = append(hours, Forecast{
+			Dt:         int(now.Add(time.Duration(i+1) * time.Hour).Unix()),
+			Conditions: map[string]float64{"temp": temp, "clouds": 0, "dew_point": -4},
+		})
+	}
+
+	risk, frostIn, ok := frostRisk(now, hours, nil)
+	require.True(t, ok)
+	require.Equal(t, 4.0, frostIn)
+	require.Equal(t, 0.75, risk)
+
+	// Beyond the hourly forecast, the daily minima are used.
+	days := []Forecast{
+		{Dt: int(now.Add(-6 * time.Hour).Unix()), Conditions: map[string]float64{"temp_min": -10}},
+		{Dt: int(now.Add(42 * time.Hour).Unix()), Conditions: map[string]float64{"temp_min": -3, "clouds": 100}},
+	}
+
+	risk, frostIn, ok = frostRisk(now, hours[:2], days)
+	require.True(t, ok)
+	require.Equal(t, 42.0, frostIn)
+	require.Equal(t, 0.0, risk)
+
+	_, _, ok = frostRisk(now, hours[:2], nil)
+	require.False(t, ok)
+}
@@ -0,0 +1,280 @@
+package owm
+
+import (
+	"math"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The standard starting values of the moisture codes.
+const (
+	fwiStartFFMC = 85
+	fwiStartDMC  = 6
+	fwiStartDC   = 15
+)
+
+var (
+	metricFireWeatherIndexDesc = prometheus.NewDesc(
+		"weather_fire_weather_index",
+		"Canadian Forest Fire Weather Index system codes and indices, calculated daily from the noon conditions",
+		[]string{"location", "index"},
+		nil,
+	)
+
+	// dmcDayLength is the effective day length of each month used by the
+	// Duff Moisture Code.
+	dmcDayLength = [12]float64{6.5, 7.5, 9, 12.8, 13.9, 13.9, 12.4, 10.9, 9.4, 8, 7, 6}
+	// dcDayLength is the day length adjustment of each month used by the
+	// Drought Code.
+	dcDayLength = [12]float64{-1.6, -1.6, -1.6, 0.9, 3.8, 5.8, 6.4, 5, 2.4, 0.4, -1.6, -1.6}
+)
+
+// fwiState is the Fire Weather Index system of a location, carried from day
+// to day.
+type fwiState struct {
+	// Day is the local date the indices were last calculated for.
+	Day  string  `json:"day"`
+	FFMC float64 `json:"ffmc"`
+	DMC  float64 `json:"dmc"`
+	DC   float64 `json:"dc"`
+	ISI  float64 `json:"isi"`
+	BUI  float64 `json:"bui"`
+	FWI  float64 `json:"fwi"`
+	// Rain is the rain in mm since the indices were last calculated, up to
+	// the observation at Dt.
+	Rain float64 `json:"rain"`
+	Dt   int     `json:"dt"`
+}
+
+// ffmc returns the Fine Fuel Moisture Code from the previous code, the noon
+// temperature in °C, relative humidity in %, wind speed in km/h and the rain
+// of the last 24 hours in mm.
+func ffmc(prev, temp, rh, wind, rain float64) float64 {
+	mo := 147.2 * (101 - prev) / (59.5 + prev)
+
+	if rain > 0.5 {
+		rf := rain - 0.5
+		mr := mo + 42.5*rf*math.Exp(-100/(251-mo))*(1-math.Exp(-6.93/rf))
+		if mo > 150 {
+			mr += 0.0015 * math.Pow(mo-150, 2) * math.Sqrt(rf)
+		}
+		mo = math.Min(mr, 250)
+	}
+
+	ed := 0.942*math.Pow(rh, 0.679) + 11*math.Exp((rh-100)/10) + 0.18*(21.1-temp)*(1-math.Exp(-0.115*rh))
+
+	m := mo
+	switch {
+	case mo > ed:
+		ko := 0.424*(1-math.Pow(rh/100, 1.7)) + 0.0694*math.Sqrt(wind)*(1-math.Pow(rh/100, 8))
+		kd := ko * 0.581 * math.Exp(0.0365*temp)
+		m = ed + (mo-ed)*math.Pow(10, -kd)
+
+	default:
+		ew := 0.618*math.Pow(rh, 0.753) + 10*math.Exp((rh-100)/10) + 0.18*(21.1-temp)*(1-math.Exp(-0.115*rh))
+		if mo < ew {
+			kl := 0.424*(1-math.Pow((100-rh)/100, 1.7)) + 0.0694*math.Sqrt(wind)*(1-math.Pow((100-rh)/100, 8))
+			kw := kl * 0.581 * math.Exp(0.0365*temp)
+			m = ew - (ew-mo)*math.Pow(10, -kw)
+		}
+	}
+
+	return math.Max(0, math.Min(101, 59.5*(250-m)/(147.2+m)))
+}
+
+// dmc returns the Duff Moisture Code from the previous code, the noon
+// temperature in °C, relative humidity in %, the rain of the last 24 hours in
+// mm and the month.
+func dmc(prev, temp, rh, rain float64, month time.Month) float64 {
+	temp = math.Max(temp, -1.1)
+	rk := 1.894 * (temp + 1.1) * (100 - rh) * dmcDayLength[month-1] * 1e-4
+
+	pr := prev
+	if rain > 1.5 {
+		rw := 0.92*rain - 1.27
+		wmi := 20 + 280/math.Exp(0.023*prev)
+
+		var b float64
+		switch {
+		case prev <= 33:
+			b = 100 / (0.5 + 0.3*prev)
+		case prev <= 65:
+			b = 14 - 1.3*math.Log(prev)
+		default:
+			b = 6.2*math.Log(prev) - 17.2
+		}
+
+		wmr := wmi + 1000*rw/(48.77+b*rw)
+		pr = math.Max(0, 43.43*(5.6348-math.Log(wmr-20)))
+	}
+
+	return math.Max(0, pr+rk)
+}
+
+// dc returns the Drought Code from the previous code, the noon temperature in
+// °C, the rain of the last 24 hours in mm and the month.
+func dc(prev, temp, rain float64, month time.Month) float64 {
+	temp = math.Max(temp, -2.8)
+	pe := math.Max(0, (0.36*(temp+2.8)+dcDayLength[month-1])/2)
+
+	dr := prev
+	if rain > 2.8 {
+		rd := 0.83*rain - 1.27
+		qr := 800*math.Exp(-prev/400) + 3.937*rd
+		dr = math.Max(0, 400*math.Log(800/qr))
+	}
+
+	return math.Max(0, dr+pe)
+}
+
+// isi returns the Initial Spread Index from the Fine Fuel Moisture Code and
+// the wind speed in km/h.
+func isi(ffmc, wind float64) float64 {
+	fm := 147.2 * (101 - ffmc) / (59.5 + ffmc)
+	sf := 19.115 * math.Exp(-0.1386*fm) * (1 + math.Pow(fm, 5.31)/4.93e7)
+
+	return sf * math.Exp(0.05039*wind)
+}
+
+// bui returns the Buildup Index from the Duff Moisture and Drought Codes.
+func bui(dmc, dc float64) float64 {
+	if dmc == 0 {
+		return 0
+	}
+
+	if dmc <= 0.4*dc {
+		return 0.8 * dc * dmc / (dmc + 0.4*dc)
+	}
+
+	return math.Max(0, dmc-(1-0.8*dc/(dmc+0.4*dc))*(0.92+math.Pow(0.0114*dmc, 1.7)))
+}
+
+// fwi returns the Fire Weather Index from the Initial Spread and Buildup
+// Indices.
+func fwi(isi, bui float64) float64 {
+	var fd float64
+	if bui <= 80 {
+		fd = 0.626*math.Pow(bui, 0.809) + 2
+	} else {
+		fd = 1000 / (25 + 108.64*math.Exp(-0.023*bui))
+	}
+
+	b := 0.1 * isi * fd
+	if b <= 1 {
+		return b
+	}
+
+	return math.Exp(2.72 * math.Pow(0.434*math.Log(b), 0.647))
+}
+
+// calculate advances the codes and indices by a day from the noon conditions
+// and the rain since the last calculation.
+func (s *fwiState) calculate(temp, rh, wind float64, month time.Month) {
+	if s.Day == "" {
+		s.FFMC, s.DMC, s.DC = fwiStartFFMC, fwiStartDMC, fwiStartDC
+	}
+
+	rh = math.Min(rh, 100)
+
+	s.FFMC = ffmc(s.FFMC, temp, rh, wind, s.Rain)
+	s.DMC = dmc(s.DMC, temp, rh, s.Rain, month)
+	s.DC = dc(s.DC, temp, s.Rain, month)
+	s.ISI = isi(s.FFMC, wind)
+	s.BUI = bui(s.DMC, s.DC)
+	s.FWI = fwi(s.ISI, s.BUI)
+	s.Rain = 0
+}
+
+// add records the conditions observed at the local time t, accumulating the
+// rain and calculating the day's indices from the first observation from
+// noon.
+func (s *fwiState) add(t time.Time, conditions map[string]float64) {
+	dt := int(t.Unix())
+	if dt <= s.Dt {
+		return
+	}
+
+	rain := conditions["rain_1h"]
+
+	// Hourly rain is counted for the time since the last observation only.
+	if s.Dt != 0 {
+		s.Rain += rain * math.Min(1, float64(dt-s.Dt)/3600)
+	}
+	s.Dt = dt
+
+	day := t.Format("2006-01-02")
+	if day <= s.Day || t.Hour() < 12 {
+		return
+	}
+
+	temp, ok := conditions["temp"]
+	if !ok {
+		return
+	}
+
+	rh, ok := conditions["humidity"]
+	if !ok {
+		return
+	}
+
+	// Wind speed is given in m/s.
+	s.calculate(temp, rh, conditions["wind_speed"]*3.6, t.Month())
+	s.Day = day
+}
+
+// emitFireWeatherIndex updates the Fire Weather Index system of a location
+// from the current conditions, and sends the codes and indices once they have
+// been calculated.
+func (o *OWM) emitFireWeatherIndex(ch chan<- prometheus.Metric, location Location, weather *Weather) {
+	c := weather.Current
+	if c == nil {
+		return
+	}
+
+	t := time.Unix(int64(c.Dt), 0).In(weather.location())
+
+	o.mtx.Lock()
+	if o.state.FWI == nil {
+		o.state.FWI = make(map[string]*fwiState)
+	}
+
+	s, ok := o.state.FWI[location.Name]
+	if !ok {
+		s = &fwiState{}
+		o.state.FWI[location.Name] = s
+	}
+
+	before := *s
+	s.add(t, c.Conditions)
+	current := *s
+
+	if current != before {
+		if err := o.saveState(); err != nil {
+			_ = level.Error(o.logger).Log("msg", "failed to save state", "err", err)
+		}
+	}
+	o.mtx.Unlock()
+
+	if current.Day == "" {
+		return
+	}
+
+	for index, value := range map[string]float64{
+		"ffmc": current.FFMC,
+		"dmc":  current.DMC,
+		"dc":   current.DC,
+		"isi":  current.ISI,
+		"bui":  current.BUI,
+		"fwi":  current.FWI,
+	} {
+		ch <- prometheus.MustNewConstMetric(
+			metricFireWeatherIndexDesc,
+			prometheus.GaugeValue,
+			value,
+			location.Name,
+			index,
+		)
+	}
+}
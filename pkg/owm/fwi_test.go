@@ -0,0 +1,80 @@
+package owm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFireWeatherIndex(t *testing.T) {
+	// The first day of the Van Wagner and Pickett test data, whose table
+	// gives the FWI rounded to 10.1.
+	s := &fwiState{}
+	s.calculate(17, 42, 25, time.April)
+
+	require.InDelta(t, 87.69, s.FFMC, 0.01)
+	require.InDelta(t, 8.55, s.DMC, 0.01)
+	require.InDelta(t, 19.01, s.DC, 0.01)
+	require.InDelta(t, 10.85, s.ISI, 0.01)
+	require.InDelta(t, 8.49, s.BUI, 0.01)
+	require.InDelta(t, 10.1, s.FWI, 0.01)
+
+	// Rain wets the fuels.
+	s.Rain = 10
+	s.calculate(12, 80, 10, time.April)
+	require.Less(t, s.FFMC, 87.69)
+	require.Less(t, s.DMC, 8.55)
+	require.Less(t, s.DC, 19.01)
+	require.Zero(t, s.Rain)
+}
+
+func TestFireWeatherIndexState(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state.json")
+
+	o := newTestOWM(Config{StateFile: file}, nil)
+	require.NoError(t, o.loadState())
+
+	location := Location{Name: "ridge", FWI: true}
+	start := time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	emit := func(at time.Duration, conditions map[string]float64) []collected {
+		return collectMetrics(t, func(ch chan<- prometheus.Metric) {
+			o.emitFireWeatherIndex(ch, location, &Weather{
+				Current:  &Current{Dt: int(start.Add(at).Unix()), Conditions: conditions},
+				Timezone: "UTC",
+			})
+		})
+	}
+
+	// Nothing is calculated before noon.
+	require.Empty(t, emit(0, map[string]float64{"temp": 15, "humidity": 50, "wind_speed": 5, "rain_1h": 0.8}))
+
+	// Rain is counted for the time since the last observation, and a
+	// repeated observation is ignored.
+	require.Empty(t, emit(30*time.Minute, map[string]float64{"temp": 15, "humidity": 50, "wind_speed": 5, "rain_1h": 0.8}))
+	require.Empty(t, emit(30*time.Minute, map[string]float64{"temp": 15, "humidity": 50, "wind_speed": 5, "rain_1h": 0.8}))
+	require.InDelta(t, 0.4, o.state.FWI["ridge"].Rain, 1e-9)
+
+	metrics := emit(2*time.Hour, map[string]float64{"temp": 17, "humidity": 42, "wind_speed": 25 / 3.6})
+	require.Len(t, metrics, 6)
+
+	values := map[string]float64{}
+	for _, m := range metrics {
+		values[m.label("index")] = m.GetGauge().GetValue()
+	}
+	// Light rain is caught by the canopy before reaching the fuels.
+	require.InDelta(t, 10.1, values["fwi"], 0.01)
+
+	// The indices are calculated once a day, and carried over a restart.
+	emit(3*time.Hour, map[string]float64{"temp": 30, "humidity": 10, "wind_speed": 10})
+
+	restarted := newTestOWM(Config{StateFile: file}, nil)
+	require.NoError(t, restarted.loadState())
+
+	s := restarted.state.FWI["ridge"]
+	require.Equal(t, "2022-04-01", s.Day)
+	require.InDelta(t, 87.69, s.FFMC, 0.01)
+}
@@ -0,0 +1,131 @@
+package owm
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metricGrowingDegreeDaysDesc = prometheus.NewDesc(
+	"weather_growing_degree_days",
+	"Growing degree days accumulated since the start of the season, including today so far",
+	[]string{"location"},
+	nil,
+)
+
+// gddState is the growing season accumulation of a location.
+type gddState struct {
+	// Season is the date the current season started, eg: 2022-04-01.
+	Season string  `json:"season"`
+	Total  float64 `json:"total"`
+	// Day is the local date Min and Max were observed on.
+	Day string  `json:"day"`
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// season returns the start of the season the local time t falls in.
+func (g *GDD) season(t time.Time) string {
+	start := g.SeasonStart
+	if start == "" {
+		start = "01-01"
+	}
+
+	s := fmt.Sprintf("%d-%s", t.Year(), start)
+	if t.Format("2006-01-02") < s {
+		s = fmt.Sprintf("%d-%s", t.Year()-1, start)
+	}
+
+	return s
+}
+
+// degreeDays returns the growing degree days of a day with the minimum and
+// maximum temperatures, by the modified average method: the maximum is capped
+// at the upper threshold, if any, and both are raised to the base.
+func (g *GDD) degreeDays(min, max float64) float64 {
+	if g.Upper > 0 {
+		max = math.Min(max, g.Upper)
+		min = math.Min(min, g.Upper)
+	}
+
+	min = math.Max(min, g.Base)
+	max = math.Max(max, g.Base)
+
+	return (min+max)/2 - g.Base
+}
+
+// add records the temperature observed at the local time t, completing the
+// previous day when a new one starts.
+func (s *gddState) add(g *GDD, t time.Time, temp float64) {
+	day := t.Format("2006-01-02")
+	if day == s.Day {
+		s.Min = math.Min(s.Min, temp)
+		s.Max = math.Max(s.Max, temp)
+		return
+	}
+
+	if day < s.Day {
+		return
+	}
+
+	season := g.season(t)
+
+	// Complete the previous day, if it is in this season.
+	if s.Day != "" && s.Day >= season {
+		s.Total += g.degreeDays(s.Min, s.Max)
+	}
+
+	if s.Season != season {
+		s.Season, s.Total = season, 0
+	}
+
+	s.Day, s.Min, s.Max = day, temp, temp
+}
+
+// emitGrowingDegreeDays accumulates the growing degree days of a location
+// from the current temperature, and sends the season total.
+func (o *OWM) emitGrowingDegreeDays(ch chan<- prometheus.Metric, location Location, weather *Weather) {
+	c := weather.Current
+	if c == nil {
+		return
+	}
+
+	temp, ok := c.Conditions["temp"]
+	if !ok {
+		return
+	}
+
+	t := time.Unix(int64(c.Dt), 0).In(weather.location())
+
+	o.mtx.Lock()
+	if o.state.GDD == nil {
+		o.state.GDD = make(map[string]*gddState)
+	}
+
+	s, ok := o.state.GDD[location.Name]
+	if !ok {
+		s = &gddState{}
+		o.state.GDD[location.Name] = s
+	}
+
+	before := *s
+	s.add(location.GDD, t, temp)
+	total := s.Total + location.GDD.degreeDays(s.Min, s.Max)
+
+	if *s != before {
+		if err := o.saveState(); err != nil {
+			_ = level.Error(o.logger).Log("msg", "failed to save state", "err", err)
+		}
+	}
+	o.mtx.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(
+		metricGrowingDegreeDaysDesc,
+		prometheus.CounterValue,
+		total,
+		location.Name,
+	)
+}
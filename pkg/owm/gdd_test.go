@@ -0,0 +1,77 @@
+package owm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGDD(t *testing.T) {
+	g := &GDD{Base: 10, Upper: 30, SeasonStart: "04-01"}
+
+	require.Equal(t, 10.0, g.degreeDays(15, 25))
+	// The minimum is raised to the base and the maximum capped.
+	require.Equal(t, 10.0, g.degreeDays(5, 35))
+	require.Equal(t, 0.0, g.degreeDays(0, 8))
+
+	require.Equal(t, "2022-04-01", g.season(time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)))
+	require.Equal(t, "2021-04-01", g.season(time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC)))
+
+	s := &gddState{}
+	day := time.Date(2022, 3, 30, 6, 0, 0, 0, time.UTC)
+
+	// The end of the previous season.
+	s.add(g, day, 15)
+	s.add(g, day.Add(8*time.Hour), 25)
+	s.add(g, day.Add(24*time.Hour), 15)
+	s.add(g, day.Add(32*time.Hour), 25)
+	require.Equal(t, "2021-04-01", s.Season)
+	require.Equal(t, 10.0, s.Total)
+
+	// The first day of the season starts again from zero.
+	s.add(g, day.Add(48*time.Hour), 20)
+	require.Equal(t, "2022-04-01", s.Season)
+	require.Equal(t, 0.0, s.Total)
+	s.add(g, day.Add(56*time.Hour), 30)
+	s.add(g, day.Add(72*time.Hour), 10)
+	require.Equal(t, 15.0, s.Total)
+
+	// Observations from earlier days are ignored.
+	s.add(g, day, 40)
+	require.Equal(t, 15.0, s.Total)
+	require.Equal(t, 10.0, s.Min)
+}
+
+func TestGrowingDegreeDaysState(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state.json")
+
+	o := newTestOWM(Config{StateFile: file}, nil)
+	require.NoError(t, o.loadState())
+
+	location := Location{Name: "orchard", GDD: &GDD{Base: 10}}
+	start := time.Date(2022, 6, 1, 6, 0, 0, 0, time.UTC)
+
+	ch := make(chan prometheus.Metric, 10)
+	for _, obs := range []struct {
+		at   time.Duration
+		temp float64
+	}{{0, 12}, {8 * time.Hour, 24}, {24 * time.Hour, 14}} {
+		o.emitGrowingDegreeDays(ch, location, &Weather{
+			Current:  &Current{Dt: int(start.Add(obs.at).Unix()), Conditions: map[string]float64{"temp": obs.temp}},
+			Timezone: "UTC",
+		})
+	}
+	close(ch)
+
+	// A restart picks up the season so far.
+	restarted := newTestOWM(Config{StateFile: file}, nil)
+	require.NoError(t, restarted.loadState())
+
+	s := restarted.state.GDD["orchard"]
+	require.Equal(t, 8.0, s.Total)
+	require.Equal(t, "2022-06-02", s.Day)
+	require.Equal(t, 14.0, s.Min)
+}
@@ -0,0 +1,63 @@
+package owm
+
+import (
+	"context"
+
+	owm "github.com/briandowns/openweathermap"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// maxGroupIDs is the number of city IDs the group endpoint accepts per request.
+const maxGroupIDs = 20
+
+// collectGroup collects the current conditions for the locations identified by
+// a city ID, batching them through the group endpoint.
+func (o *OWM) collectGroup(ctx context.Context, ch chan<- prometheus.Metric, locations []Location) {
+	ctx, span := o.tracer.Start(ctx, "collectGroup")
+	defer span.End()
+
+	for start := 0; start < len(locations); start += maxGroupIDs {
+		end := start + maxGroupIDs
+		if end > len(locations) {
+			end = len(locations)
+		}
+
+		if err := o.collectGroupBatch(ctx, ch, locations[start:end]); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			_ = level.Error(o.logger).Log("msg", "current weather group failed", "err", err)
+		}
+	}
+}
+
+func (o *OWM) collectGroupBatch(ctx context.Context, ch chan<- prometheus.Metric, locations []Location) error {
+	g, err := owm.NewCurrentGroup("C", "EN", o.cfg.APIKey, owm.WithHttpClient(o.client))
+	if err != nil {
+		return err
+	}
+
+	// Locations may share a city ID, which is only requested once.
+	ids := make([]int, 0, len(locations))
+	byID := make(map[int][]Location, len(locations))
+	for _, location := range locations {
+		if _, ok := byID[location.CityID]; !ok {
+			ids = append(ids, location.CityID)
+		}
+		byID[location.CityID] = append(byID[location.CityID], location)
+	}
+
+	if err = g.CurrentByIDs(ids...); err != nil {
+		return err
+	}
+
+	for _, w := range g.List {
+		for _, location := range byID[w.ID] {
+			o.emitWeather(ctx, ch, ProviderOpenWeatherMap, location, &Weather{Current: currentWeather(w)})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package owm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newTestOWM(cfg Config, rt roundTripFunc) *OWM {
+	o := &OWM{
+		cfg:    cfg,
+		logger: log.NewNopLogger(),
+		tracer: otel.Tracer("test"),
+		client: &http.Client{Transport: rt},
+
+		stationPushed: make(map[string]map[string]float64),
+		observed:      make(map[string]observation),
+		state:         &state{},
+	}
+
+	o.stationUploads, o.stationLastUpload = newStationMetrics()
+
+	return o
+}
+
+// collected is a metric sent by a collector, written out.
+type collected struct {
+	desc string
+	*dto.Metric
+}
+
+// label returns the value of the label name.
+func (c collected) label(name string) string {
+	for _, l := range c.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+
+	return ""
+}
+
+// collectMetrics returns the metrics sent by collect.
+func collectMetrics(t *testing.T, collect func(ch chan<- prometheus.Metric)) []collected {
+	ch := make(chan prometheus.Metric, 10000)
+	collect(ch)
+	close(ch)
+
+	var metrics []collected
+	for m := range ch {
+		pb := &dto.Metric{}
+		require.NoError(t, m.Write(pb))
+		metrics = append(metrics, collected{m.Desc().String(), pb})
+	}
+
+	return metrics
+}
+
+func jsonResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestCollectGroup(t *testing.T) {
+	var locations []Location
+	for i := 1; i <= 45; i++ {
+		locations = append(locations, Location{Name: fmt.Sprintf("city%d", i), CityID: i})
+	}
+
+	requests := 0
+	o := newTestOWM(Config{}, func(req *http.Request) (*http.Response, error) {
+		requests++
+
+		ids := strings.Split(req.URL.Query().Get("id"), ",")
+		require.LessOrEqual(t, len(ids), maxGroupIDs)
+
+		list := make([]string, 0, len(ids))
+		for _, id := range ids {
+			list = append(list, fmt.Sprintf(`{"id":%s,"name":"city%s","dt":1,"main":{"temp":20}}`, id, id))
+		}
+
+		return jsonResponse(fmt.Sprintf(`{"cnt":%d,"list":[%s]}`, len(list), strings.Join(list, ","))), nil
+	})
+
+	ch := make(chan prometheus.Metric, 10000)
+	o.collectGroup(context.Background(), ch, locations)
+	close(ch)
+
+	require.Equal(t, 3, requests)
+
+	temps := 0
+	for m := range ch {
+		if strings.Contains(m.Desc().String(), "weather_current") {
+			temps++
+		}
+	}
+	// 12 conditions and 4 comfort indices, humidex needing a humidity.
+	require.Equal(t, 45*16, temps)
+}
+
+func TestCollectGroupSharedID(t *testing.T) {
+	locations := []Location{
+		{Name: "home", CityID: 2643743},
+		{Name: "office", CityID: 2643743},
+	}
+
+	o := newTestOWM(Config{}, func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "2643743", req.URL.Query().Get("id"))
+
+		return jsonResponse(`{"cnt":1,"list":[{"id":2643743,"name":"London","dt":1,"main":{"temp":20}}]}`), nil
+	})
+
+	metrics := collectMetrics(t, func(ch chan<- prometheus.Metric) {
+		o.collectGroup(context.Background(), ch, locations)
+	})
+
+	// Each location is kept, under its own name.
+	names := make(map[string]bool)
+	for _, m := range metrics {
+		names[m.label("location")] = true
+	}
+	require.Equal(t, map[string]bool{"home": true, "office": true}, names)
+}
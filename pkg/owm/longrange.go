@@ -0,0 +1,94 @@
+package owm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	owm "github.com/briandowns/openweathermap"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// maxForecastDays is the longest daily forecast offered by the API.
+const maxForecastDays = 16
+
+var metricWeatherLongRangeDesc = prometheus.NewDesc(
+	"weather_forecast_long_range",
+	"Weather condition daily long range forecast",
+	[]string{"location", "provider", "condition", "future_days"},
+	nil,
+)
+
+// collectLongRange collects the 16 day daily forecast for a location.
+func (o *OWM) collectLongRange(ctx context.Context, ch chan<- prometheus.Metric, location Location) {
+	_, span := o.tracer.Start(ctx, "collectLongRange")
+	defer span.End()
+
+	coord := &owm.Coordinates{
+		Longitude: location.Longitude,
+		Latitude:  location.Latitude,
+	}
+
+	f, err := owm.NewForecast("16", "C", "EN", o.cfg.APIKey, owm.WithHttpClient(o.client))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		_ = level.Error(o.logger).Log("msg", "failed to create long range forecast", "err", err)
+		return
+	}
+
+	if err = f.DailyByCoordinates(coord, o.cfg.ForecastDays); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		_ = level.Error(o.logger).Log("msg", "long range forecast failed", "location", location.Name, "err", err)
+		return
+	}
+
+	data, ok := f.ForecastWeatherJson.(*owm.Forecast16WeatherData)
+	if !ok {
+		return
+	}
+
+	for _, day := range data.List {
+		if day.Dt == 0 {
+			continue
+		}
+
+		conditions := map[string]float64{
+			"clouds":      float64(day.Clouds),
+			"humidity":    float64(day.Humidity),
+			"pressure":    day.Pressure,
+			"rain":        day.Rain,
+			"snow":        day.Snow,
+			"temp_day":    day.Temp.Day,
+			"temp_eve":    day.Temp.Eve,
+			"temp_max":    day.Temp.Max,
+			"temp_min":    day.Temp.Min,
+			"temp_morn":   day.Temp.Morn,
+			"temp_night":  day.Temp.Night,
+			"wind_degree": float64(day.Deg),
+			"wind_speed":  day.Speed,
+		}
+
+		for condition, value := range conditions {
+			ch <- prometheus.MustNewConstMetric(
+				metricWeatherLongRangeDesc,
+				prometheus.GaugeValue,
+				value,
+				location.Name,
+				ProviderOpenWeatherMap,
+				condition,
+				futureDays(day.Dt),
+			)
+		}
+	}
+}
+
+// futureDays returns the future_days label value for the unix time dt.
+func futureDays(dt int) string {
+	days := time.Until(time.Unix(int64(dt), 0)).Round(24*time.Hour) / (24 * time.Hour)
+
+	return fmt.Sprintf("%dd", int(days))
+}
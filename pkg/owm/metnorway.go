@@ -0,0 +1,274 @@
+package owm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	metNorwayURL = "https://api.met.no/weatherapi/locationforecast/2.0/%s?lat=%.4f&lon=%.4f"
+
+	// Formats of the locationforecast.  Complete adds dew point, gusts,
+	// probability of precipitation and percentiles to compact.
+	metNorwayCompact  = "compact"
+	metNorwayComplete = "complete"
+)
+
+// metNorwayInstant maps the instant details of a forecast to conditions.
+var metNorwayInstant = map[string]string{
+	"air_pressure_at_sea_level":   "pressure",
+	"air_temperature":             "temp",
+	"cloud_area_fraction":         "clouds",
+	"dew_point_temperature":       "dew_point",
+	"fog_area_fraction":           "fog",
+	"relative_humidity":           "humidity",
+	"ultraviolet_index_clear_sky": "uvi_clear_sky",
+	"wind_from_direction":         "wind_degree",
+	"wind_speed":                  "wind_speed",
+	"wind_speed_of_gust":          "wind_gust",
+}
+
+// metNorwaySymbols maps words in a weather symbol code to the main summary in
+// the style of OpenWeatherMap, checked in order.
+var metNorwaySymbols = []struct {
+	word, main string
+}{
+	{"thunder", "Thunderstorm"},
+	{"sleet", "Snow"},
+	{"snow", "Snow"},
+	{"rain", "Rain"},
+	{"fog", "Fog"},
+	{"cloudy", "Clouds"},
+	{"fair", "Clear"},
+	{"clearsky", "Clear"},
+}
+
+type metNorwayResponse struct {
+	Properties struct {
+		Timeseries []struct {
+			Time time.Time `json:"time"`
+			Data struct {
+				Instant struct {
+					Details map[string]float64 `json:"details"`
+				} `json:"instant"`
+				Next1Hours *struct {
+					Summary struct {
+						SymbolCode string `json:"symbol_code"`
+					} `json:"summary"`
+					Details map[string]float64 `json:"details"`
+				} `json:"next_1_hours"`
+			} `json:"data"`
+		} `json:"timeseries"`
+	} `json:"properties"`
+}
+
+// metNorwayCache is the last forecast read for a location.  MET Norway asks
+// that a forecast is not requested again before it expires, and then only
+// conditionally on it having been modified.
+type metNorwayCache struct {
+	url          string
+	expires      time.Time
+	lastModified string
+	resp         *metNorwayResponse
+}
+
+// metNorwayProvider reads forecasts from the MET Norway locationforecast API.
+// It reports no observations, so the forecast for the current hour is used as
+// the current conditions.
+type metNorwayProvider struct {
+	o *OWM
+
+	mtx   sync.Mutex
+	cache map[string]*metNorwayCache
+}
+
+func newMETNorwayProvider(o *OWM) *metNorwayProvider {
+	return &metNorwayProvider{
+		o:     o,
+		cache: make(map[string]*metNorwayCache),
+	}
+}
+
+func (p *metNorwayProvider) Name() string {
+	return ProviderMETNorway
+}
+
+func (p *metNorwayProvider) Weather(ctx context.Context, location Location) (*Weather, error) {
+	ctx, span := p.o.tracer.Start(ctx, "metNorwayWeather")
+	defer span.End()
+
+	resp, err := p.forecast(ctx, location)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	weather := &Weather{}
+
+	for _, ts := range resp.Properties.Timeseries {
+		// Beyond the first days the forecast is given for 6 hour periods.
+		if ts.Data.Next1Hours == nil {
+			break
+		}
+
+		conditions := make(map[string]float64, len(ts.Data.Instant.Details)+2)
+		for detail, value := range ts.Data.Instant.Details {
+			if condition, ok := metNorwayInstant[detail]; ok {
+				conditions[condition] = value
+			}
+		}
+
+		if v, ok := ts.Data.Next1Hours.Details["precipitation_amount"]; ok {
+			conditions[metNorwayPrecipitation(ts.Data.Next1Hours.Summary.SymbolCode, conditions)] = v
+		}
+		if v, ok := ts.Data.Next1Hours.Details["probability_of_precipitation"]; ok {
+			conditions["pop"] = v / 100
+		}
+
+		if !ts.Time.After(now) {
+			weather.Current = &Current{
+				Dt:         int(ts.Time.Unix()),
+				Conditions: conditions,
+				Summary:    metNorwaySummary(ts.Data.Next1Hours.Summary.SymbolCode),
+			}
+			continue
+		}
+
+		weather.Hourly = append(weather.Hourly, Forecast{
+			Dt:         int(ts.Time.Unix()),
+			Conditions: conditions,
+		})
+	}
+
+	return weather, nil
+}
+
+// AirQuality is not offered by the locationforecast.
+func (p *metNorwayProvider) AirQuality(ctx context.Context, location Location) (*AirQuality, error) {
+	return nil, nil
+}
+
+// forecast returns the forecast for a location, read from the cache until it
+// expires.
+func (p *metNorwayProvider) forecast(ctx context.Context, location Location) (*metNorwayResponse, error) {
+	format := p.o.cfg.METNorwayFormat
+	if format == "" {
+		format = metNorwayCompact
+	}
+
+	url := fmt.Sprintf(metNorwayURL, format, location.Latitude, location.Longitude)
+	if location.Altitude != 0 {
+		url += fmt.Sprintf("&altitude=%.0f", location.Altitude)
+	}
+
+	// Cache entries are replaced rather than modified, so that the request
+	// is made without the lock held.
+	p.mtx.Lock()
+	cached, ok := p.cache[location.Name]
+	p.mtx.Unlock()
+
+	if ok && cached.url != url {
+		cached, ok = nil, false
+	}
+
+	if ok && time.Now().Before(cached.expires) {
+		return cached.resp, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", p.o.cfg.UserAgent)
+	if ok && cached.lastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.lastModified)
+	}
+
+	resp, err := p.o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	expires, _ := http.ParseTime(resp.Header.Get("Expires"))
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		renewed := *cached
+		renewed.expires = expires
+		p.store(location.Name, &renewed)
+
+		return cached.resp, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var forecast metNorwayResponse
+	if err := json.NewDecoder(resp.Body).Decode(&forecast); err != nil {
+		return nil, err
+	}
+
+	p.store(location.Name, &metNorwayCache{
+		url:          url,
+		expires:      expires,
+		lastModified: resp.Header.Get("Last-Modified"),
+		resp:         &forecast,
+	})
+
+	return &forecast, nil
+}
+
+func (p *metNorwayProvider) store(location string, cached *metNorwayCache) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.cache[location] = cached
+}
+
+// metNorwayPrecipitation returns the condition the precipitation of an hour
+// is given as, snow_1h when the symbol is of snow, or without a symbol at
+// temperatures below freezing, and otherwise rain_1h.  Sleet counts as rain.
+func metNorwayPrecipitation(symbol string, conditions map[string]float64) string {
+	if symbol != "" {
+		if strings.Contains(symbol, "snow") {
+			return "snow_1h"
+		}
+
+		return "rain_1h"
+	}
+
+	if t, ok := conditions["temp"]; ok && t < 0 {
+		return "snow_1h"
+	}
+
+	return "rain_1h"
+}
+
+// metNorwaySummary converts a weather symbol code, eg: lightrainshowers_day.
+func metNorwaySummary(symbol string) []Summary {
+	if symbol == "" {
+		return nil
+	}
+
+	description := symbol
+	if i := strings.Index(symbol, "_"); i > 0 {
+		description = symbol[:i]
+	}
+
+	for _, s := range metNorwaySymbols {
+		if strings.Contains(description, s.word) {
+			return []Summary{{Main: s.main, Description: description}}
+		}
+	}
+
+	return []Summary{{Main: description, Description: description}}
+}
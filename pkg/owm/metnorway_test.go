@@ -0,0 +1,82 @@
+package owm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMETNorwayWeather(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	body := `{"properties": {"timeseries": [
+		{"time": "` + now.Format(time.RFC3339) + `", "data": {
+			"instant": {"details": {"air_temperature": 4.2, "wind_speed": 3.1, "cloud_area_fraction": 80}},
+			"next_1_hours": {"summary": {"symbol_code": "lightrainshowers_day"}, "details": {"precipitation_amount": 0.4}}}},
+		{"time": "` + now.Add(time.Hour).Format(time.RFC3339) + `", "data": {
+			"instant": {"details": {"air_temperature": 5}},
+			"next_1_hours": {"summary": {"symbol_code": "cloudy"}, "details": {"probability_of_precipitation": 30}}}},
+		{"time": "` + now.Add(6*time.Hour).Format(time.RFC3339) + `", "data": {
+			"instant": {"details": {"air_temperature": 6}}}}]}}`
+
+	lastModified := now.Format(http.TimeFormat)
+
+	var requests []*http.Request
+	o := newTestOWM(Config{UserAgent: "test (test@example.com)"}, func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+
+		header := http.Header{}
+		header.Set("Expires", time.Now().Add(time.Hour).Format(http.TimeFormat))
+		header.Set("Last-Modified", lastModified)
+
+		if req.Header.Get("If-Modified-Since") == lastModified {
+			return &http.Response{StatusCode: http.StatusNotModified, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+
+		resp := jsonResponse(body)
+		resp.Header = header
+		return resp, nil
+	})
+
+	p := newMETNorwayProvider(o)
+	location := Location{Name: "oslo", Latitude: 59.913868, Longitude: 10.752245, Altitude: 23}
+
+	w, err := p.Weather(context.Background(), location)
+	require.NoError(t, err)
+
+	require.Len(t, requests, 1)
+	require.Equal(t, "test (test@example.com)", requests[0].Header.Get("User-Agent"))
+	require.Equal(t, "lat=59.9139&lon=10.7522&altitude=23", requests[0].URL.RawQuery)
+	require.Equal(t, "/weatherapi/locationforecast/2.0/compact", requests[0].URL.Path)
+
+	require.Equal(t, map[string]float64{"temp": 4.2, "wind_speed": 3.1, "clouds": 80, "rain_1h": 0.4}, w.Current.Conditions)
+	require.Equal(t, []Summary{{"Rain", "lightrainshowers"}}, w.Current.Summary)
+	require.Len(t, w.Hourly, 1)
+	require.Equal(t, map[string]float64{"temp": 5, "pop": 0.3}, w.Hourly[0].Conditions)
+
+	// Unexpired forecasts are not requested again.
+	_, err = p.Weather(context.Background(), location)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+
+	// Expired forecasts are requested again, conditionally.
+	p.cache[location.Name].expires = time.Now().Add(-time.Minute)
+
+	w, err = p.Weather(context.Background(), location)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	require.Equal(t, lastModified, requests[1].Header.Get("If-Modified-Since"))
+	require.Len(t, w.Hourly, 1)
+	require.True(t, p.cache[location.Name].expires.After(time.Now()))
+}
+
+func TestMETNorwayPrecipitation(t *testing.T) {
+	require.Equal(t, "snow_1h", metNorwayPrecipitation("heavysnowshowers_night", map[string]float64{"temp": 1}))
+	require.Equal(t, "rain_1h", metNorwayPrecipitation("lightsleet", map[string]float64{"temp": -1}))
+	require.Equal(t, "snow_1h", metNorwayPrecipitation("", map[string]float64{"temp": -1}))
+	require.Equal(t, "rain_1h", metNorwayPrecipitation("", map[string]float64{}))
+}
@@ -0,0 +1,98 @@
+package owm
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	metricMoonEventDesc = prometheus.NewDesc(
+		"moon_event_timestamp_seconds",
+		"Time of the moon events of the day: (moonrise|moonset)",
+		[]string{"location", "provider", "event", "future_days"},
+		nil,
+	)
+
+	metricMoonPhaseDesc = prometheus.NewDesc(
+		"moon_phase",
+		"Moon phase of the day: 0 and 1 are new moon, 0.5 is full moon",
+		[]string{"location", "provider", "future_days"},
+		nil,
+	)
+
+	metricMoonIlluminationDesc = prometheus.NewDesc(
+		"moon_illumination_fraction",
+		"Illuminated fraction of the moon's disk",
+		[]string{"location"},
+		nil,
+	)
+)
+
+// emitMoon sends the moon events and phases from a provider for today and the
+// coming days at a location.  Today's moonrise and moonset are also sent as
+// epochs, next to sunrise and sunset.
+func (o *OWM) emitMoon(ch chan<- prometheus.Metric, provider string, location Location, days []MoonDay) {
+	for i, day := range days {
+		if i == 0 {
+			o.emitEpochs(ch, provider, location, map[string]float64{
+				"moonrise": float64(day.Moonrise),
+				"moonset":  float64(day.Moonset),
+			})
+		}
+
+		future := futureDays(day.Dt)
+
+		// Days without a moonrise or moonset have it as zero.
+		events := map[string]int{
+			"moonrise": day.Moonrise,
+			"moonset":  day.Moonset,
+		}
+
+		for event, t := range events {
+			if t == 0 {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				metricMoonEventDesc,
+				prometheus.GaugeValue,
+				float64(t),
+				location.Name,
+				provider,
+				event,
+				future,
+			)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			metricMoonPhaseDesc,
+			prometheus.GaugeValue,
+			day.Phase,
+			location.Name,
+			provider,
+			future,
+		)
+	}
+}
+
+// moonIllumination returns the illuminated fraction of the moon's disk at t,
+// from its phase angle by the low accuracy method of Meeus, chapter 48.
+func moonIllumination(t time.Time) float64 {
+	c := julianDays(t) / 36525
+
+	d := (297.8501921 + 445267.1114034*c) * deg2rad
+	m := (357.5291092 + 35999.0502909*c) * deg2rad
+	mp := (134.9633964 + 477198.8675055*c) * deg2rad
+
+	i := math.Pi - d +
+		(-6.289*math.Sin(mp)+
+			2.100*math.Sin(m)-
+			1.274*math.Sin(2*d-mp)-
+			0.658*math.Sin(2*d)-
+			0.214*math.Sin(2*mp)-
+			0.110*math.Sin(d))*deg2rad
+
+	return (1 + math.Cos(i)) / 2
+}
@@ -0,0 +1,50 @@
+package owm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitMoon(t *testing.T) {
+	o := newTestOWM(Config{}, nil)
+
+	today := time.Now().Truncate(time.Hour)
+	days := []MoonDay{
+		{Dt: int(today.Unix()), Moonrise: int(today.Add(2 * time.Hour).Unix()), Moonset: int(today.Add(14 * time.Hour).Unix()), Phase: 0.5},
+		// No moonset tomorrow.
+		{Dt: int(today.Add(24 * time.Hour).Unix()), Moonrise: int(today.Add(27 * time.Hour).Unix()), Phase: 0.53},
+	}
+
+	ch := make(chan prometheus.Metric, 20)
+	o.emitMoon(ch, ProviderOpenWeatherMap, Location{Name: "observatory"}, days)
+	close(ch)
+
+	counts := map[string]int{}
+	for m := range ch {
+		for _, name := range []string{"weather_epoch", "moon_event_timestamp_seconds", "moon_phase"} {
+			if strings.Contains(m.Desc().String(), `"`+name+`"`) {
+				counts[name]++
+			}
+		}
+	}
+
+	require.Equal(t, map[string]int{
+		"weather_epoch":                2,
+		"moon_event_timestamp_seconds": 3,
+		"moon_phase":                   2,
+	}, counts)
+}
+
+func TestMoonIllumination(t *testing.T) {
+	// Meeus, example 48.a.
+	require.InDelta(t, 0.6786, moonIllumination(time.Date(1992, 4, 12, 0, 0, 0, 0, time.UTC)), 0.01)
+
+	// Full, new and first quarter moons of autumn 2020.
+	require.InDelta(t, 1, moonIllumination(time.Date(2020, 10, 31, 14, 49, 0, 0, time.UTC)), 0.01)
+	require.InDelta(t, 0, moonIllumination(time.Date(2020, 11, 15, 5, 7, 0, 0, time.UTC)), 0.01)
+	require.InDelta(t, 0.5, moonIllumination(time.Date(2020, 11, 22, 4, 45, 0, 0, time.UTC)), 0.02)
+}
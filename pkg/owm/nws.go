@@ -0,0 +1,368 @@
+package owm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	nwsPointsURL = "https://api.weather.gov/points/%.4f,%.4f"
+	nwsAlertsURL = "https://api.weather.gov/alerts/active?point=%.4f,%.4f"
+	// nwsObservationURL is the latest observation from a station.
+	nwsObservationURL = "https://api.weather.gov/stations/%s/observations/latest"
+
+	// nwsForecastHours is the number of hourly forecast periods kept, matching
+	// the 48 hours of the One Call API.
+	nwsForecastHours = 48
+)
+
+// nwsCompass is the bearing in degrees of each compass point used for wind
+// direction in the forecast.
+var nwsCompass = map[string]float64{
+	"N": 0, "NNE": 22.5, "NE": 45, "ENE": 67.5,
+	"E": 90, "ESE": 112.5, "SE": 135, "SSE": 157.5,
+	"S": 180, "SSW": 202.5, "SW": 225, "WSW": 247.5,
+	"W": 270, "WNW": 292.5, "NW": 315, "NNW": 337.5,
+}
+
+// nwsValue is a quantity with a WMO unit code, null when missing.
+type nwsValue struct {
+	UnitCode string   `json:"unitCode"`
+	Value    *float64 `json:"value"`
+}
+
+type nwsPointResponse struct {
+	Properties struct {
+		ForecastHourly      string `json:"forecastHourly"`
+		ObservationStations string `json:"observationStations"`
+		TimeZone            string `json:"timeZone"`
+	} `json:"properties"`
+}
+
+type nwsStationsResponse struct {
+	Features []struct {
+		Properties struct {
+			StationIdentifier string `json:"stationIdentifier"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+type nwsForecastResponse struct {
+	Properties struct {
+		Periods []struct {
+			StartTime                  time.Time `json:"startTime"`
+			Temperature                *float64  `json:"temperature"`
+			Dewpoint                   nwsValue  `json:"dewpoint"`
+			RelativeHumidity           nwsValue  `json:"relativeHumidity"`
+			ProbabilityOfPrecipitation nwsValue  `json:"probabilityOfPrecipitation"`
+			WindSpeed                  string    `json:"windSpeed"`
+			WindDirection              string    `json:"windDirection"`
+		} `json:"periods"`
+	} `json:"properties"`
+}
+
+type nwsObservationResponse struct {
+	Properties struct {
+		Timestamp             time.Time `json:"timestamp"`
+		TextDescription       string    `json:"textDescription"`
+		Temperature           nwsValue  `json:"temperature"`
+		Dewpoint              nwsValue  `json:"dewpoint"`
+		RelativeHumidity      nwsValue  `json:"relativeHumidity"`
+		WindDirection         nwsValue  `json:"windDirection"`
+		WindSpeed             nwsValue  `json:"windSpeed"`
+		WindGust              nwsValue  `json:"windGust"`
+		BarometricPressure    nwsValue  `json:"barometricPressure"`
+		SeaLevelPressure      nwsValue  `json:"seaLevelPressure"`
+		Visibility            nwsValue  `json:"visibility"`
+		PrecipitationLastHour nwsValue  `json:"precipitationLastHour"`
+	} `json:"properties"`
+}
+
+type nwsAlertsResponse struct {
+	Features []struct {
+		Properties struct {
+			Event     string     `json:"event"`
+			Severity  string     `json:"severity"`
+			Urgency   string     `json:"urgency"`
+			Certainty string     `json:"certainty"`
+			Onset     *time.Time `json:"onset"`
+			Expires   *time.Time `json:"expires"`
+			Ends      *time.Time `json:"ends"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+// nwsPoint is the forecast and observation station resolved for a location.
+type nwsPoint struct {
+	forecastHourly string
+	station        string
+	timezone       string
+}
+
+// nwsProvider reads forecasts, observations and alerts from the US National
+// Weather Service, which covers the United States only.
+type nwsProvider struct {
+	o *OWM
+
+	mtx    sync.Mutex
+	points map[string]nwsPoint
+}
+
+func newNWSProvider(o *OWM) *nwsProvider {
+	return &nwsProvider{
+		o:      o,
+		points: make(map[string]nwsPoint),
+	}
+}
+
+func (p *nwsProvider) Name() string {
+	return ProviderNWS
+}
+
+func (p *nwsProvider) Weather(ctx context.Context, location Location) (*Weather, error) {
+	ctx, span := p.o.tracer.Start(ctx, "nwsWeather")
+	defer span.End()
+
+	point, err := p.point(ctx, location)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	var forecast nwsForecastResponse
+	if err := p.get(ctx, point.forecastHourly+"?units=si", &forecast); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to get forecast: %w", err)
+	}
+
+	weather := &Weather{Timezone: point.timezone}
+
+	for _, period := range forecast.Properties.Periods {
+		if len(weather.Hourly) == nwsForecastHours {
+			break
+		}
+
+		conditions := make(map[string]float64)
+		setValue(conditions, "temp", period.Temperature, 1)
+		setValue(conditions, "dew_point", period.Dewpoint.Value, 1)
+		setValue(conditions, "humidity", period.RelativeHumidity.Value, 1)
+		setValue(conditions, "pop", period.ProbabilityOfPrecipitation.Value, 0.01)
+
+		if speed, ok := nwsWindSpeed(period.WindSpeed); ok {
+			conditions["wind_speed"] = speed
+		}
+		if degree, ok := nwsCompass[period.WindDirection]; ok {
+			conditions["wind_degree"] = degree
+		}
+
+		weather.Hourly = append(weather.Hourly, Forecast{
+			Dt:         int(period.StartTime.Unix()),
+			Conditions: conditions,
+		})
+	}
+
+	// Observations and alerts are reported when available, without failing
+	// the forecast.
+	if point.station != "" {
+		current, err := p.observation(ctx, point.station)
+		if err != nil {
+			_ = level.Warn(p.o.logger).Log("msg", "nws observation failed", "location", location.Name, "station", point.station, "err", err)
+		} else {
+			weather.Current = current
+		}
+	}
+
+	alerts, err := p.alerts(ctx, location)
+	if err != nil {
+		_ = level.Warn(p.o.logger).Log("msg", "nws alerts failed", "location", location.Name, "err", err)
+	}
+	weather.Alerts = alerts
+
+	return weather, nil
+}
+
+// AirQuality is not offered by the NWS.
+func (p *nwsProvider) AirQuality(ctx context.Context, location Location) (*AirQuality, error) {
+	return nil, nil
+}
+
+// point resolves the gridpoint forecast and nearest observation station for a
+// location, which are cached as they do not change.
+func (p *nwsProvider) point(ctx context.Context, location Location) (nwsPoint, error) {
+	p.mtx.Lock()
+	point, ok := p.points[location.Name]
+	p.mtx.Unlock()
+
+	if ok {
+		return point, nil
+	}
+
+	var resp nwsPointResponse
+	if err := p.get(ctx, fmt.Sprintf(nwsPointsURL, location.Latitude, location.Longitude), &resp); err != nil {
+		return point, fmt.Errorf("failed to resolve gridpoint: %w", err)
+	}
+
+	point.forecastHourly = resp.Properties.ForecastHourly
+	point.timezone = resp.Properties.TimeZone
+	if point.forecastHourly == "" {
+		return point, fmt.Errorf("no forecast for %f,%f", location.Latitude, location.Longitude)
+	}
+
+	if resp.Properties.ObservationStations != "" {
+		var stations nwsStationsResponse
+		if err := p.get(ctx, resp.Properties.ObservationStations, &stations); err != nil {
+			return point, fmt.Errorf("failed to list observation stations: %w", err)
+		}
+
+		// Stations are listed nearest first.
+		if len(stations.Features) > 0 {
+			point.station = stations.Features[0].Properties.StationIdentifier
+		}
+	}
+
+	p.mtx.Lock()
+	p.points[location.Name] = point
+	p.mtx.Unlock()
+
+	return point, nil
+}
+
+// observation reads the latest observation from a station.
+func (p *nwsProvider) observation(ctx context.Context, station string) (*Current, error) {
+	var resp nwsObservationResponse
+	if err := p.get(ctx, fmt.Sprintf(nwsObservationURL, station), &resp); err != nil {
+		return nil, err
+	}
+
+	obs := resp.Properties
+
+	conditions := make(map[string]float64)
+	setValue(conditions, "temp", obs.Temperature.Value, 1)
+	setValue(conditions, "dew_point", obs.Dewpoint.Value, 1)
+	setValue(conditions, "humidity", obs.RelativeHumidity.Value, 1)
+	setValue(conditions, "wind_degree", obs.WindDirection.Value, 1)
+	setValue(conditions, "wind_speed", obs.WindSpeed.Value, 1/3.6)
+	setValue(conditions, "wind_gust", obs.WindGust.Value, 1/3.6)
+	setValue(conditions, "visibility", obs.Visibility.Value, 1)
+
+	// Precipitation has been given in both metres and millimetres.
+	rainScale := 1.0
+	if strings.HasSuffix(obs.PrecipitationLastHour.UnitCode, ":m") {
+		rainScale = 1000
+	}
+	setValue(conditions, "rain_1h", obs.PrecipitationLastHour.Value, rainScale)
+
+	// Pressures are given in Pa.
+	if obs.SeaLevelPressure.Value != nil {
+		setValue(conditions, "pressure", obs.SeaLevelPressure.Value, 0.01)
+	} else {
+		setValue(conditions, "pressure", obs.BarometricPressure.Value, 0.01)
+	}
+
+	current := &Current{
+		Dt:         int(obs.Timestamp.Unix()),
+		Conditions: conditions,
+	}
+
+	if obs.TextDescription != "" {
+		current.Summary = []Summary{{Main: obs.TextDescription, Description: strings.ToLower(obs.TextDescription)}}
+	}
+
+	return current, nil
+}
+
+// alerts reads the alerts in effect at a location.
+func (p *nwsProvider) alerts(ctx context.Context, location Location) ([]Alert, error) {
+	var resp nwsAlertsResponse
+	if err := p.get(ctx, fmt.Sprintf(nwsAlertsURL, location.Latitude, location.Longitude), &resp); err != nil {
+		return nil, err
+	}
+
+	alerts := make([]Alert, 0, len(resp.Features))
+	for _, f := range resp.Features {
+		a := Alert{
+			Event:     f.Properties.Event,
+			Severity:  f.Properties.Severity,
+			Urgency:   f.Properties.Urgency,
+			Certainty: f.Properties.Certainty,
+		}
+
+		if f.Properties.Onset != nil {
+			a.Onset = int(f.Properties.Onset.Unix())
+		}
+
+		// The end of the event is preferred to the expiry of the message,
+		// which is often replaced by an update before the event ends.
+		switch {
+		case f.Properties.Ends != nil:
+			a.Expires = int(f.Properties.Ends.Unix())
+		case f.Properties.Expires != nil:
+			a.Expires = int(f.Properties.Expires.Unix())
+		}
+
+		alerts = append(alerts, a)
+	}
+
+	return alerts, nil
+}
+
+// get decodes the JSON document at url into v, identifying the exporter with
+// the configured User-Agent as the API requires.
+func (p *nwsProvider) get(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", p.o.cfg.UserAgent)
+	req.Header.Set("Accept", "application/geo+json")
+
+	resp, err := p.o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// setValue sets condition to the scaled value when it is not null.
+func setValue(conditions map[string]float64, condition string, value *float64, scale float64) {
+	if value != nil {
+		conditions[condition] = *value * scale
+	}
+}
+
+// nwsWindSpeed parses a forecast wind speed in km/h, eg: "15 km/h" or
+// "10 to 15 km/h", returning the highest speed in m/s.
+func nwsWindSpeed(s string) (float64, bool) {
+	var speed float64
+	var ok bool
+	for _, f := range strings.Fields(s) {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			continue
+		}
+
+		ok = true
+		if v > speed {
+			speed = v
+		}
+	}
+
+	return speed / 3.6, ok
+}
@@ -0,0 +1,91 @@
+package owm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNWSWeather(t *testing.T) {
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	responses := map[string]string{
+		"/points/39.7456,-97.0892": `{"properties": {
+			"forecastHourly": "https://api.weather.gov/gridpoints/TOP/32,81/forecast/hourly",
+			"observationStations": "https://api.weather.gov/gridpoints/TOP/32,81/stations"}}`,
+		"/gridpoints/TOP/32,81/stations": `{"features": [
+			{"properties": {"stationIdentifier": "KMYZ"}},
+			{"properties": {"stationIdentifier": "KCNK"}}]}`,
+		"/gridpoints/TOP/32,81/forecast/hourly": `{"properties": {"periods": [{
+			"startTime": "2024-05-01T10:00:00-05:00", "temperature": 18,
+			"dewpoint": {"unitCode": "wmoUnit:degC", "value": 9.4},
+			"relativeHumidity": {"unitCode": "wmoUnit:percent", "value": 56},
+			"probabilityOfPrecipitation": {"unitCode": "wmoUnit:percent", "value": 20},
+			"windSpeed": "18 km/h", "windDirection": "SW"}]}}`,
+		"/stations/KMYZ/observations/latest": `{"properties": {
+			"timestamp": "2024-05-01T14:55:00+00:00", "textDescription": "Mostly Cloudy",
+			"temperature": {"unitCode": "wmoUnit:degC", "value": 17.2},
+			"windSpeed": {"unitCode": "wmoUnit:km_h-1", "value": 36},
+			"windGust": {"unitCode": "wmoUnit:km_h-1", "value": null},
+			"seaLevelPressure": {"unitCode": "wmoUnit:Pa", "value": 101320},
+			"barometricPressure": {"unitCode": "wmoUnit:Pa", "value": 97000}}}`,
+		"/alerts/active": `{"features": [{"properties": {
+			"event": "Tornado Warning", "severity": "Extreme", "urgency": "Immediate",
+			"certainty": "Observed", "expires": "` + expires + `", "ends": null}}]}`,
+	}
+
+	requests := make(map[string]int)
+	o := newTestOWM(Config{UserAgent: "test (test@example.com)"}, func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "test (test@example.com)", req.Header.Get("User-Agent"))
+
+		requests[req.URL.Path]++
+		body, ok := responses[req.URL.Path]
+		require.True(t, ok, req.URL.Path)
+
+		return jsonResponse(body), nil
+	})
+
+	p := newNWSProvider(o)
+	location := Location{Name: "home", Latitude: 39.74561, Longitude: -97.08921}
+
+	for i := 0; i < 2; i++ {
+		w, err := p.Weather(context.Background(), location)
+		require.NoError(t, err)
+
+		require.Len(t, w.Hourly, 1)
+		require.Equal(t, map[string]float64{
+			"temp":        18,
+			"dew_point":   9.4,
+			"humidity":    56,
+			"pop":         0.2,
+			"wind_speed":  5,
+			"wind_degree": 225,
+		}, w.Hourly[0].Conditions)
+
+		require.Equal(t, map[string]float64{
+			"temp":       17.2,
+			"wind_speed": 10,
+			"pressure":   1013.2,
+		}, w.Current.Conditions)
+		require.Equal(t, []Summary{{"Mostly Cloudy", "mostly cloudy"}}, w.Current.Summary)
+
+		require.Len(t, w.Alerts, 1)
+		require.Equal(t, "Extreme", w.Alerts[0].Severity)
+	}
+
+	// The gridpoint is resolved once.
+	require.Equal(t, 1, requests["/points/39.7456,-97.0892"])
+	require.Equal(t, 2, requests["/gridpoints/TOP/32,81/forecast/hourly"])
+}
+
+func TestNWSWindSpeed(t *testing.T) {
+	speed, ok := nwsWindSpeed("18 to 36 km/h")
+	require.True(t, ok)
+	require.InDelta(t, 10, speed, 1e-9)
+
+	_, ok = nwsWindSpeed("")
+	require.False(t, ok)
+}
@@ -0,0 +1,277 @@
+package owm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	openMeteoForecastURL   = "https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&current=%s&hourly=%s&daily=%s&timeformat=unixtime&timezone=auto&wind_speed_unit=ms"
+	openMeteoAirQualityURL = "https://air-quality-api.open-meteo.com/v1/air-quality?latitude=%f&longitude=%f&current=%s&timeformat=unixtime"
+)
+
+// openMeteoSnowWater converts snowfall in centimetres to its water equivalent
+// in mm, taking 7 cm of snow to be 10 mm of water as Open-Meteo does.
+const openMeteoSnowWater = 10.0 / 7
+
+// openMeteoVariable maps an Open-Meteo variable to a condition, scaling it to
+// the units used by the other providers.  Variables mapped to the same
+// condition are summed.
+type openMeteoVariable struct {
+	condition string
+	scale     float64
+}
+
+var (
+	openMeteoCurrent = map[string]openMeteoVariable{
+		"apparent_temperature": {"feels_like", 1},
+		"cloud_cover":          {"clouds", 1},
+		"dew_point_2m":         {"dew_point", 1},
+		"pressure_msl":         {"pressure", 1},
+		"relative_humidity_2m": {"humidity", 1},
+		"temperature_2m":       {"temp", 1},
+		"uv_index":             {"uvi", 1},
+		"visibility":           {"visibility", 1},
+		"wind_direction_10m":   {"wind_degree", 1},
+		"wind_gusts_10m":       {"wind_gust", 1},
+		"wind_speed_10m":       {"wind_speed", 1},
+	}
+
+	openMeteoHourly = map[string]openMeteoVariable{
+		"apparent_temperature": {"feels_like", 1},
+		"cloud_cover":          {"clouds", 1},
+		"dew_point_2m":         {"dew_point", 1},
+		"pressure_msl":         {"pressure", 1},
+		// Rain excludes showers, which are reported separately.
+		"rain":                 {"rain_1h", 1},
+		"relative_humidity_2m": {"humidity", 1},
+		"showers":              {"rain_1h", 1},
+		"snowfall":             {"snow_1h", openMeteoSnowWater},
+		"temperature_2m":       {"temp", 1},
+		"uv_index":             {"uvi", 1},
+		"visibility":           {"visibility", 1},
+		"wind_direction_10m":   {"wind_degree", 1},
+		"wind_gusts_10m":       {"wind_gust", 1},
+		"wind_speed_10m":       {"wind_speed", 1},
+	}
+
+	openMeteoDaily = map[string]openMeteoVariable{
+		"precipitation_probability_max": {"pop", 0.01},
+		"rain_sum":                      {"rain", 1},
+		"showers_sum":                   {"rain", 1},
+		"snowfall_sum":                  {"snow", openMeteoSnowWater},
+		"temperature_2m_max":            {"temp_max", 1},
+		"temperature_2m_min":            {"temp_min", 1},
+		"uv_index_max":                  {"uvi", 1},
+		"wind_direction_10m_dominant":   {"wind_degree", 1},
+		"wind_gusts_10m_max":            {"wind_gust", 1},
+		"wind_speed_10m_max":            {"wind_speed", 1},
+	}
+
+	openMeteoAirQuality = map[string]openMeteoVariable{
+		"alder_pollen":     {"alder_pollen", 1},
+		"ammonia":          {"nh3", 1},
+		"birch_pollen":     {"birch_pollen", 1},
+		"carbon_monoxide":  {"co", 1},
+		"grass_pollen":     {"grass_pollen", 1},
+		"mugwort_pollen":   {"mugwort_pollen", 1},
+		"nitrogen_dioxide": {"no2", 1},
+		"olive_pollen":     {"olive_pollen", 1},
+		"ozone":            {"o3", 1},
+		"pm10":             {"pm10", 1},
+		"pm2_5":            {"pm2_5", 1},
+		"ragweed_pollen":   {"ragweed_pollen", 1},
+		"sulphur_dioxide":  {"so2", 1},
+	}
+)
+
+// wmoCodes maps the WMO weather interpretation codes used by Open-Meteo to a
+// summary in the style of OpenWeatherMap.
+var wmoCodes = map[int]Summary{
+	0:  {"Clear", "clear sky"},
+	1:  {"Clouds", "mainly clear"},
+	2:  {"Clouds", "partly cloudy"},
+	3:  {"Clouds", "overcast"},
+	45: {"Fog", "fog"},
+	48: {"Fog", "depositing rime fog"},
+	51: {"Drizzle", "light drizzle"},
+	53: {"Drizzle", "moderate drizzle"},
+	55: {"Drizzle", "dense drizzle"},
+	56: {"Drizzle", "light freezing drizzle"},
+	57: {"Drizzle", "dense freezing drizzle"},
+	61: {"Rain", "slight rain"},
+	63: {"Rain", "moderate rain"},
+	65: {"Rain", "heavy rain"},
+	66: {"Rain", "light freezing rain"},
+	67: {"Rain", "heavy freezing rain"},
+	71: {"Snow", "slight snow fall"},
+	73: {"Snow", "moderate snow fall"},
+	75: {"Snow", "heavy snow fall"},
+	77: {"Snow", "snow grains"},
+	80: {"Rain", "slight rain showers"},
+	81: {"Rain", "moderate rain showers"},
+	82: {"Rain", "violent rain showers"},
+	85: {"Snow", "slight snow showers"},
+	86: {"Snow", "heavy snow showers"},
+	95: {"Thunderstorm", "thunderstorm"},
+	96: {"Thunderstorm", "thunderstorm with slight hail"},
+	99: {"Thunderstorm", "thunderstorm with heavy hail"},
+}
+
+type openMeteoForecast struct {
+	Timezone string                `json:"timezone"`
+	Current  map[string]*float64   `json:"current"`
+	Hourly   map[string][]*float64 `json:"hourly"`
+	Daily    map[string][]*float64 `json:"daily"`
+}
+
+type openMeteoAirQualityResponse struct {
+	Current map[string]*float64 `json:"current"`
+}
+
+// openMeteoProvider reads weather and air quality from Open-Meteo, which
+// needs no API key.
+type openMeteoProvider struct {
+	o *OWM
+}
+
+func (p *openMeteoProvider) Name() string {
+	return ProviderOpenMeteo
+}
+
+func (p *openMeteoProvider) Weather(ctx context.Context, location Location) (*Weather, error) {
+	ctx, span := p.o.tracer.Start(ctx, "openMeteoWeather")
+	defer span.End()
+
+	current := append(variables(openMeteoCurrent), "weather_code")
+	daily := append(variables(openMeteoDaily), "sunrise", "sunset")
+
+	url := fmt.Sprintf(openMeteoForecastURL, location.Latitude, location.Longitude,
+		strings.Join(current, ","),
+		strings.Join(variables(openMeteoHourly), ","),
+		strings.Join(daily, ","),
+	)
+
+	var resp openMeteoForecast
+	if err := p.o.getJSON(ctx, url, &resp); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	weather := &Weather{
+		Daily:    openMeteoSeries(resp.Daily, openMeteoDaily),
+		Timezone: resp.Timezone,
+	}
+
+	// The hourly forecast starts at local midnight, so the hours already
+	// passed are dropped.
+	now := time.Now()
+	for _, hour := range openMeteoSeries(resp.Hourly, openMeteoHourly) {
+		if time.Unix(int64(hour.Dt), 0).Add(time.Hour).After(now) {
+			weather.Hourly = append(weather.Hourly, hour)
+		}
+	}
+
+	if t := resp.Current["time"]; t != nil {
+		c := &Current{
+			Dt:         int(*t),
+			Conditions: openMeteoConditions(resp.Current, openMeteoCurrent),
+		}
+
+		if code := resp.Current["weather_code"]; code != nil {
+			if summary, ok := wmoCodes[int(*code)]; ok {
+				c.Summary = []Summary{summary}
+			}
+		}
+
+		// Today's sunrise and sunset come with the daily forecast.
+		if sunrise := resp.Daily["sunrise"]; len(sunrise) > 0 && sunrise[0] != nil {
+			c.Sunrise = int(*sunrise[0])
+		}
+		if sunset := resp.Daily["sunset"]; len(sunset) > 0 && sunset[0] != nil {
+			c.Sunset = int(*sunset[0])
+		}
+
+		weather.Current = c
+	}
+
+	return weather, nil
+}
+
+func (p *openMeteoProvider) AirQuality(ctx context.Context, location Location) (*AirQuality, error) {
+	ctx, span := p.o.tracer.Start(ctx, "openMeteoAirQuality")
+	defer span.End()
+
+	current := append(variables(openMeteoAirQuality), "european_aqi")
+
+	url := fmt.Sprintf(openMeteoAirQualityURL, location.Latitude, location.Longitude, strings.Join(current, ","))
+
+	var resp openMeteoAirQualityResponse
+	if err := p.o.getJSON(ctx, url, &resp); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	aqi := resp.Current["european_aqi"]
+	if aqi == nil {
+		return nil, nil
+	}
+
+	return &AirQuality{
+		AQI:        *aqi,
+		Components: openMeteoConditions(resp.Current, openMeteoAirQuality),
+	}, nil
+}
+
+func variables(m map[string]openMeteoVariable) []string {
+	vars := make([]string, 0, len(m))
+	for v := range m {
+		vars = append(vars, v)
+	}
+
+	return vars
+}
+
+// openMeteoConditions converts the variables of a single time, skipping those
+// without a value.
+func openMeteoConditions(values map[string]*float64, mapping map[string]openMeteoVariable) map[string]float64 {
+	conditions := make(map[string]float64, len(mapping))
+	for v, m := range mapping {
+		if value := values[v]; value != nil {
+			conditions[m.condition] += *value * m.scale
+		}
+	}
+
+	return conditions
+}
+
+// openMeteoSeries converts the variables of a time series.
+func openMeteoSeries(series map[string][]*float64, mapping map[string]openMeteoVariable) []Forecast {
+	times := series["time"]
+
+	forecasts := make([]Forecast, 0, len(times))
+	for i, t := range times {
+		if t == nil {
+			continue
+		}
+
+		values := make(map[string]*float64, len(mapping))
+		for v := range mapping {
+			if i < len(series[v]) {
+				values[v] = series[v][i]
+			}
+		}
+
+		forecasts = append(forecasts, Forecast{
+			Dt:         int(math.Round(*t)),
+			Conditions: openMeteoConditions(values, mapping),
+		})
+	}
+
+	return forecasts
+}
@@ -0,0 +1,50 @@
+package owm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenMeteoWeather(t *testing.T) {
+	hour := time.Now().Truncate(time.Hour).Unix()
+
+	o := newTestOWM(Config{}, func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "api.open-meteo.com", req.URL.Host)
+
+		return jsonResponse(fmt.Sprintf(`{
+			"current": {"time": 1700000000, "temperature_2m": 12.5, "weather_code": 61, "uv_index": null},
+			"hourly": {"time": [%d, %d, %d], "temperature_2m": [11, 12.5, null],
+				"rain": [0, 0.5, 0], "showers": [0, 1.5, 0], "snowfall": [0, 0.7, 0]},
+			"daily": {"time": [1699999200], "sunrise": [1700020000], "sunset": [1700050000],
+				"precipitation_probability_max": [40], "rain_sum": [2], "showers_sum": [3], "snowfall_sum": [1.4]}
+		}`, hour-7200, hour, hour+3600)), nil
+	})
+
+	p := &openMeteoProvider{o: o}
+	w, err := p.Weather(context.Background(), Location{Name: "home"})
+	require.NoError(t, err)
+
+	require.Equal(t, 1700000000, w.Current.Dt)
+	require.Equal(t, 1700020000, w.Current.Sunrise)
+	require.Equal(t, 1700050000, w.Current.Sunset)
+	require.Equal(t, map[string]float64{"temp": 12.5}, w.Current.Conditions)
+	require.Equal(t, []Summary{{"Rain", "slight rain"}}, w.Current.Summary)
+
+	// The hours already passed are dropped, and showers are rain.
+	require.Len(t, w.Hourly, 2)
+	require.Equal(t, int(hour), w.Hourly[0].Dt)
+	require.Equal(t, 12.5, w.Hourly[0].Conditions["temp"])
+	require.Equal(t, 2.0, w.Hourly[0].Conditions["rain_1h"])
+	require.InDelta(t, 1, w.Hourly[0].Conditions["snow_1h"], 1e-9)
+	require.Equal(t, map[string]float64{"rain_1h": 0, "snow_1h": 0}, w.Hourly[1].Conditions)
+
+	require.Len(t, w.Daily, 1)
+	require.InDelta(t, 0.4, w.Daily[0].Conditions["pop"], 1e-9)
+	require.Equal(t, 5.0, w.Daily[0].Conditions["rain"])
+	require.InDelta(t, 2, w.Daily[0].Conditions["snow"], 1e-9)
+}
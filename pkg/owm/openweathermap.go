@@ -0,0 +1,224 @@
+package owm
+
+import (
+	"context"
+
+	owm "github.com/briandowns/openweathermap"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+var errOneCallUnavailable = errors.New("onecall returned no data")
+
+// owmProvider reads weather from OpenWeatherMap, using the APIs selected by
+// the configured mode.
+type owmProvider struct {
+	o *OWM
+}
+
+func (p *owmProvider) Name() string {
+	return ProviderOpenWeatherMap
+}
+
+func (p *owmProvider) Weather(ctx context.Context, location Location) (*Weather, error) {
+	switch p.o.cfg.APIMode {
+	case APIModeFree:
+		return p.free(ctx, location)
+	case APIModeAuto:
+		w, err := p.oneCall(ctx, location)
+		if err != nil {
+			_ = level.Warn(p.o.logger).Log("msg", "onecall unavailable, falling back to free APIs", "location", location.Name, "err", err)
+			return p.free(ctx, location)
+		}
+		return w, nil
+	default:
+		return p.oneCall(ctx, location)
+	}
+}
+
+func (p *owmProvider) oneCall(ctx context.Context, location Location) (*Weather, error) {
+	_, span := p.o.tracer.Start(ctx, "oneCall")
+	defer span.End()
+
+	coord := &owm.Coordinates{
+		Longitude: location.Longitude,
+		Latitude:  location.Latitude,
+	}
+
+	// Possibility to exclude information. For example exclude daily information []string{ExcludeDaily}
+	w, err := owm.NewOneCall("C", "EN", p.o.cfg.APIKey, []string{}, owm.WithHttpClient(p.o.client))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, errors.Wrap(err, "onecall failed")
+	}
+
+	err = w.OneCallByCoordinates(coord)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, errors.Wrap(err, "onecall coordinates failed")
+	}
+
+	// The API answers keys without a One Call subscription with an error
+	// document, which decodes without error into an empty response.
+	if w.Current.Dt == 0 && len(w.Hourly) == 0 {
+		span.SetStatus(codes.Error, errOneCallUnavailable.Error())
+		return nil, errOneCallUnavailable
+	}
+
+	weather := &Weather{Timezone: w.Timezone}
+
+	if w.Current.Dt > 0 {
+		weather.Current = &Current{
+			Dt:      w.Current.Dt,
+			Sunrise: w.Current.Sunrise,
+			Sunset:  w.Current.Sunset,
+			Conditions: map[string]float64{
+				"clouds":      float64(w.Current.Clouds),
+				"dew_point":   w.Current.DewPoint,
+				"feels_like":  w.Current.FeelsLike,
+				"humidity":    float64(w.Current.Humidity),
+				"pressure":    float64(w.Current.Pressure),
+				"rain_1h":     w.Current.Rain.OneH,
+				"rain_3h":     w.Current.Rain.ThreeH,
+				"snow_1h":     w.Current.Snow.OneH,
+				"snow_3h":     w.Current.Snow.ThreeH,
+				"temp":        w.Current.Temp,
+				"uvi":         w.Current.UVI,
+				"visibility":  float64(w.Current.Visibility),
+				"wind_degree": w.Current.WindDeg,
+				"wind_gust":   w.Current.WindGust,
+				"wind_speed":  w.Current.WindSpeed,
+			},
+			Summary: summaries(w.Current.Weather),
+		}
+	}
+
+	for _, hour := range w.Hourly {
+		if hour.Dt == 0 {
+			continue
+		}
+
+		weather.Hourly = append(weather.Hourly, Forecast{Dt: hour.Dt, Conditions: map[string]float64{
+			"clouds":      float64(hour.Clouds),
+			"dew_point":   hour.DewPoint,
+			"feels_like":  hour.FeelsLike,
+			"humidity":    float64(hour.Humidity),
+			"pressure":    float64(hour.Pressure),
+			"rain_1h":     hour.Rain.OneH,
+			"rain_3h":     hour.Rain.ThreeH,
+			"snow_1h":     hour.Snow.OneH,
+			"snow_3h":     hour.Snow.ThreeH,
+			"temp":        hour.Temp,
+			"uvi":         hour.UVI,
+			"visibility":  float64(hour.Visibility),
+			"wind_degree": hour.WindDeg,
+			"wind_gust":   hour.WindGust,
+			"wind_speed":  hour.WindSpeed,
+		}})
+	}
+
+	for _, day := range w.Daily {
+		if day.Dt == 0 {
+			continue
+		}
+
+		weather.Daily = append(weather.Daily, Forecast{Dt: day.Dt, Conditions: map[string]float64{
+			"clouds":      float64(day.Clouds),
+			"dew_point":   day.DewPoint,
+			"humidity":    float64(day.Humidity),
+			"pop":         day.Pop,
+			"pressure":    float64(day.Pressure),
+			"rain":        day.Rain,
+			"snow":        day.Snow,
+			"temp_day":    day.Temp.Day,
+			"temp_eve":    day.Temp.Eve,
+			"temp_max":    day.Temp.Max,
+			"temp_min":    day.Temp.Min,
+			"temp_morn":   day.Temp.Morn,
+			"temp_night":  day.Temp.Night,
+			"uvi":         day.UVI,
+			"wind_degree": day.WindDeg,
+			"wind_gust":   day.WindGust,
+			"wind_speed":  day.WindSpeed,
+		}})
+
+		weather.Moon = append(weather.Moon, MoonDay{
+			Dt:       day.Dt,
+			Moonrise: day.Moonrise,
+			Moonset:  day.Moonset,
+			Phase:    day.MoonPhase,
+		})
+	}
+
+	// One Call alerts carry no CAP severity, urgency or certainty.
+	for _, alert := range w.Alerts {
+		weather.Alerts = append(weather.Alerts, Alert{
+			Event:     alert.Event,
+			Severity:  alertUnknown,
+			Urgency:   alertUnknown,
+			Certainty: alertUnknown,
+			Onset:     alert.Start,
+			Expires:   alert.End,
+		})
+	}
+
+	return weather, nil
+}
+
+func (p *owmProvider) AirQuality(ctx context.Context, location Location) (*AirQuality, error) {
+	_, span := p.o.tracer.Start(ctx, "collectPollution")
+	defer span.End()
+
+	coord := &owm.Coordinates{
+		Longitude: location.Longitude,
+		Latitude:  location.Latitude,
+	}
+
+	pollution, err := owm.NewPollution(p.o.cfg.APIKey, owm.WithHttpClient(p.o.client))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, errors.Wrap(err, "failed to get new pollution data")
+	}
+
+	params := &owm.PollutionParameters{
+		Location: *coord,
+		Datetime: "current",
+	}
+
+	if err := pollution.PollutionByParams(params); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, errors.Wrap(err, "failed to update pollution data")
+	}
+
+	if len(pollution.List) == 0 {
+		return nil, nil
+	}
+
+	p0 := pollution.List[0]
+
+	return &AirQuality{
+		AQI: p0.Main.Aqi,
+		Components: map[string]float64{
+			"co":    p0.Components.Co,
+			"nh3":   p0.Components.Nh3,
+			"no":    p0.Components.No,
+			"no2":   p0.Components.No2,
+			"o3":    p0.Components.O3,
+			"pm10":  p0.Components.Pm10,
+			"pm2_5": p0.Components.Pm25,
+			"so2":   p0.Components.So2,
+		},
+	}, nil
+}
+
+// summaries converts the OpenWeatherMap weather descriptions.
+func summaries(weather []owm.Weather) []Summary {
+	s := make([]Summary, 0, len(weather))
+	for _, w := range weather {
+		s = append(s, Summary{Main: w.Main, Description: w.Description})
+	}
+
+	return s
+}
@@ -0,0 +1,178 @@
+package owm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testOneCall = `{"timezone": "Europe/London",
+		"current": {"dt": 1651400000, "sunrise": 1651380000, "sunset": 1651435000,
+			"temp": 14.5, "pressure": 1012, "humidity": 70, "wind_speed": 4.1,
+			"rain": {"1h": 0.3}, "weather": [{"main": "Rain", "description": "light rain"}]},
+		"hourly": [{"dt": 1651402800, "temp": 15, "pressure": 1011, "humidity": 68}],
+		"daily": [{"dt": 1651406400, "temp": {"min": 9, "max": 17}, "rain": 2.5,
+			"moonrise": 1651390000, "moonset": 1651440000, "moon_phase": 0.05}],
+		"alerts": [{"event": "Wind warning", "start": 1651400000, "end": 1651450000}]}`
+
+	testCurrent = `{"dt": 1651400000, "main": {"temp": 14, "pressure": 1013, "humidity": 72},
+		"wind": {"speed": 3.6, "deg": 200}, "rain": {"1h": 0.2},
+		"sys": {"sunrise": 1651380000, "sunset": 1651435000},
+		"weather": [{"main": "Rain", "description": "light rain"}]}`
+
+	testForecast5 = `{"cnt": 2, "list": [
+		{"dt": 1651406400, "main": {"temp": 15, "pressure": 1011, "humidity": 65}, "rain": {"3h": 1.2}},
+		{"dt": 1651417200, "main": {"temp": 16, "pressure": 1010, "humidity": 60}}]}`
+
+	// testNoOneCall is the error document returned to keys without a One
+	// Call subscription.
+	testNoOneCall = `{"cod": 401, "message": "Please note that using One Call 3.0 requires a separate subscription"}`
+)
+
+// owmResponses serves the OpenWeatherMap APIs by path from a test server,
+// counting the requests made to each, and sends requests to it.
+func owmResponses(t *testing.T, responses map[string]string, requests map[string]int) roundTripFunc {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests[req.URL.Path]++
+
+		body, ok := responses[req.URL.Path]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	return func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+
+		return srv.Client().Transport.RoundTrip(req)
+	}
+}
+
+func TestOWMOneCall(t *testing.T) {
+	requests := make(map[string]int)
+	o := newTestOWM(Config{APIMode: APIModeOneCall, APIKey: "key"}, owmResponses(t, map[string]string{
+		"/data/2.5/onecall": testOneCall,
+	}, requests))
+
+	p := &owmProvider{o}
+	w, err := p.Weather(context.Background(), Location{Name: "home", Latitude: 51.5, Longitude: -0.1})
+	require.NoError(t, err)
+
+	require.Equal(t, "Europe/London", w.Timezone)
+	require.Equal(t, 1651400000, w.Current.Dt)
+	require.Equal(t, 14.5, w.Current.Conditions["temp"])
+	require.Equal(t, 0.3, w.Current.Conditions["rain_1h"])
+	require.Equal(t, []Summary{{"Rain", "light rain"}}, w.Current.Summary)
+
+	require.Len(t, w.Hourly, 1)
+	require.Equal(t, 1011.0, w.Hourly[0].Conditions["pressure"])
+
+	require.Len(t, w.Daily, 1)
+	require.Equal(t, 17.0, w.Daily[0].Conditions["temp_max"])
+	require.Equal(t, 2.5, w.Daily[0].Conditions["rain"])
+	require.Equal(t, []MoonDay{{Dt: 1651406400, Moonrise: 1651390000, Moonset: 1651440000, Phase: 0.05}}, w.Moon)
+
+	require.Len(t, w.Alerts, 1)
+	require.Equal(t, alertUnknown, w.Alerts[0].Severity)
+
+	require.Equal(t, map[string]int{"/data/2.5/onecall": 1}, requests)
+}
+
+func TestOWMOneCallUnavailable(t *testing.T) {
+	requests := make(map[string]int)
+	o := newTestOWM(Config{APIMode: APIModeOneCall, APIKey: "key"}, owmResponses(t, map[string]string{
+		"/data/2.5/onecall": testNoOneCall,
+	}, requests))
+
+	p := &owmProvider{o}
+	_, err := p.Weather(context.Background(), Location{Name: "home", Latitude: 51.5, Longitude: -0.1})
+	require.ErrorIs(t, err, errOneCallUnavailable)
+
+	// The free APIs are only used when configured.
+	require.Equal(t, map[string]int{"/data/2.5/onecall": 1}, requests)
+}
+
+func TestOWMFree(t *testing.T) {
+	requests := make(map[string]int)
+	o := newTestOWM(Config{APIMode: APIModeFree, APIKey: "key"}, owmResponses(t, map[string]string{
+		"/data/2.5/weather":  testCurrent,
+		"/data/2.5/forecast": testForecast5,
+	}, requests))
+
+	p := &owmProvider{o}
+	w, err := p.Weather(context.Background(), Location{Name: "home", Latitude: 51.5, Longitude: -0.1})
+	require.NoError(t, err)
+
+	require.Equal(t, 1651400000, w.Current.Dt)
+	require.Equal(t, 1651380000, w.Current.Sunrise)
+	require.Equal(t, 14.0, w.Current.Conditions["temp"])
+	require.Equal(t, 0.2, w.Current.Conditions["rain_1h"])
+	require.Equal(t, 200.0, w.Current.Conditions["wind_degree"])
+
+	require.Len(t, w.Hourly, 2)
+	require.Equal(t, 1.2, w.Hourly[0].Conditions["rain_3h"])
+	require.Equal(t, 16.0, w.Hourly[1].Conditions["temp"])
+	require.Empty(t, w.Daily)
+
+	require.Equal(t, map[string]int{"/data/2.5/weather": 1, "/data/2.5/forecast": 1}, requests)
+}
+
+func TestOWMFreeForecastOnly(t *testing.T) {
+	requests := make(map[string]int)
+	o := newTestOWM(Config{APIMode: APIModeFree, APIKey: "key"}, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/data/2.5/weather" {
+			return nil, errOneCallUnavailable
+		}
+
+		return owmResponses(t, map[string]string{"/data/2.5/forecast": testForecast5}, requests)(req)
+	})
+
+	// The forecast is kept when the current conditions fail.
+	p := &owmProvider{o}
+	w, err := p.Weather(context.Background(), Location{Name: "home", Latitude: 51.5, Longitude: -0.1})
+	require.NoError(t, err)
+	require.Nil(t, w.Current)
+	require.Len(t, w.Hourly, 2)
+}
+
+func TestOWMAuto(t *testing.T) {
+	responses := map[string]string{
+		"/data/2.5/onecall":  testOneCall,
+		"/data/2.5/weather":  testCurrent,
+		"/data/2.5/forecast": testForecast5,
+	}
+
+	requests := make(map[string]int)
+	o := newTestOWM(Config{APIMode: APIModeAuto, APIKey: "key"}, owmResponses(t, responses, requests))
+
+	// One Call is used while it is available.
+	p := &owmProvider{o}
+	w, err := p.Weather(context.Background(), Location{Name: "home", Latitude: 51.5, Longitude: -0.1})
+	require.NoError(t, err)
+	require.Len(t, w.Daily, 1)
+	require.Equal(t, map[string]int{"/data/2.5/onecall": 1}, requests)
+
+	// The free APIs are used when it returns no data.
+	responses["/data/2.5/onecall"] = testNoOneCall
+
+	w, err = p.Weather(context.Background(), Location{Name: "home", Latitude: 51.5, Longitude: -0.1})
+	require.NoError(t, err)
+	require.Equal(t, 14.0, w.Current.Conditions["temp"])
+	require.Len(t, w.Hourly, 2)
+	require.Empty(t, w.Daily)
+	require.Equal(t, map[string]int{"/data/2.5/onecall": 2, "/data/2.5/weather": 1, "/data/2.5/forecast": 1}, requests)
+}
@@ -1,8 +1,10 @@
 package owm
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -19,14 +21,59 @@ type OWM struct {
 	logger log.Logger
 	tracer trace.Tracer
 	client *http.Client
+
+	mtx        sync.Mutex
+	polygonIDs map[string]string
+	// agroPolygons are the polygons registered with the Agro API, by name.
+	agroPolygons  map[string]agroPolygon
+	stationIDs    map[string]string
+	stationPushed map[string]map[string]float64
+	observed      map[string]observation
+	// capAlerts are the CAP documents linked from each alert feed, by feed
+	// name and link.
+	capAlerts map[string]map[string]*capAlert
+	// degreeDays are accumulated from the current conditions of each
+	// provider for a location.
+	degreeDays map[sourceKey]*degreeDays
+	// pressure is the recent current pressure of each provider for a
+	// location.
+	pressure map[sourceKey]*pressureHistory
+	// solar is the irradiance read from the Solar Irradiance API for each
+	// location and date.
+	solar map[solarKey]*solarCache
+	state *state
+
+	providers map[string]Provider
+	accuracy  *forecastAccuracy
+
+	stationUploads    *prometheus.CounterVec
+	stationLastUpload *prometheus.GaugeVec
 }
 
 func New(cfg Config) (*OWM, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	o := &OWM{
 		cfg:    cfg,
 		logger: util.NewLogger(),
 		tracer: otel.Tracer("openWeatherMap"),
 		client: &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+
+		stationPushed: make(map[string]map[string]float64),
+		observed:      make(map[string]observation),
+	}
+
+	o.stationUploads, o.stationLastUpload = newStationMetrics()
+	o.providers = o.newProviders()
+
+	if err := o.loadState(); err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if cfg.ForecastAccuracy || cfg.ForecastCorrection {
+		o.accuracy = newForecastAccuracy(cfg.AccuracyWindow)
 	}
 
 	prometheus.MustRegister(o)
@@ -38,6 +85,16 @@ func (o *OWM) Run() error {
 	d := http.NewServeMux()
 	d.Handle("/metrics", promhttp.Handler())
 
+	if len(o.cfg.Stations) > 0 {
+		d.HandleFunc(stationPushPrefix, o.stationPushHandler)
+		go o.runStations(context.Background())
+	}
+
+	if len(o.cfg.LocalStations) > 0 {
+		d.HandleFunc(ecowittPath, o.receiverHandler)
+		d.HandleFunc(ambientPath, o.receiverHandler)
+	}
+
 	_ = level.Info(o.logger).Log("msg", fmt.Sprintf("openweathermap_exporter started on %s", o.cfg.ListenAddr))
 
 	defer func() { _ = level.Info(o.logger).Log("msg", "openweathermap_exporter stopped") }()
@@ -0,0 +1,177 @@
+package owm
+
+import (
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// precipitationTypes are the observed hourly precipitation conditions that
+// are accumulated, by type.
+var precipitationTypes = map[string]string{
+	"rain": "rain_1h",
+	"snow": "snow_1h",
+}
+
+var (
+	metricPrecipitationTotalDesc = prometheus.NewDesc(
+		"weather_precipitation_total",
+		"Precipitation accumulated from the current conditions (mm): (rain|snow)",
+		[]string{"location", "provider", "type"},
+		nil,
+	)
+
+	metricPrecipitationTodayDesc = prometheus.NewDesc(
+		"weather_precipitation_today",
+		"Precipitation accumulated from the current conditions since local midnight (mm): (rain|snow)",
+		[]string{"location", "provider", "type"},
+		nil,
+	)
+
+	metricPrecipitation24hDesc = prometheus.NewDesc(
+		"weather_precipitation_24h",
+		"Precipitation accumulated from the current conditions over the last 24 hours (mm): (rain|snow)",
+		[]string{"location", "provider", "type"},
+		nil,
+	)
+)
+
+// precipitationAmount is the precipitation attributed to the period up to T.
+type precipitationAmount struct {
+	T      time.Time          `json:"t"`
+	Amount map[string]float64 `json:"amount"`
+}
+
+// precipitation is the accumulation of a location from a provider, by type.
+type precipitation struct {
+	// Last is the time of the last observation counted.
+	Last time.Time `json:"last"`
+	// Day is the local date Today is accumulated for.
+	Day    string                `json:"day"`
+	Total  map[string]float64    `json:"total"`
+	Today  map[string]float64    `json:"today"`
+	Recent []precipitationAmount `json:"recent"`
+}
+
+func newPrecipitation() *precipitation {
+	return &precipitation{
+		Total: make(map[string]float64),
+		Today: make(map[string]float64),
+	}
+}
+
+// add accumulates the precipitation observed at the local time t, returning
+// whether it was counted.  The hourly amounts overlap between observations,
+// so only the part of the hour since the last observation is counted, and
+// nothing for the first observation.
+func (p *precipitation) add(t time.Time, conditions map[string]float64) bool {
+	// The same observation is read on every collection.
+	if !t.After(p.Last) {
+		return false
+	}
+
+	day := t.Format("2006-01-02")
+	if day != p.Day {
+		p.Day = day
+		p.Today = make(map[string]float64)
+	}
+
+	if p.Last.IsZero() {
+		p.Last = t
+		return true
+	}
+
+	window := t.Sub(p.Last)
+	if window > time.Hour {
+		window = time.Hour
+	}
+
+	// The part of the period that falls today, when it spans midnight.
+	today := 1.0
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if from := t.Add(-window); from.Before(midnight) {
+		today = float64(t.Sub(midnight)) / float64(window)
+	}
+
+	amount := make(map[string]float64, len(precipitationTypes))
+	for typ, condition := range precipitationTypes {
+		a := conditions[condition] * window.Hours()
+
+		amount[typ] = a
+		p.Total[typ] += a
+		p.Today[typ] += a * today
+	}
+
+	p.Last = t
+	p.Recent = append(p.Recent, precipitationAmount{t, amount})
+
+	cutoff := t.Add(-24 * time.Hour)
+	i := 0
+	for i < len(p.Recent) && !p.Recent[i].T.After(cutoff) {
+		i++
+	}
+	p.Recent = p.Recent[i:]
+
+	return true
+}
+
+// last24h returns the precipitation of each type over the 24 hours to the
+// last observation.
+func (p *precipitation) last24h() map[string]float64 {
+	sums := make(map[string]float64, len(precipitationTypes))
+
+	for _, r := range p.Recent {
+		for typ, a := range r.Amount {
+			sums[typ] += a
+		}
+	}
+
+	return sums
+}
+
+// emitPrecipitation accumulates the precipitation of a location from the
+// current conditions, and sends the totals.
+func (o *OWM) emitPrecipitation(ch chan<- prometheus.Metric, provider string, location Location, weather *Weather) {
+	c := weather.Current
+	if c == nil {
+		return
+	}
+
+	o.mtx.Lock()
+	if o.state.Precipitation == nil {
+		o.state.Precipitation = make(map[string]map[string]*precipitation)
+	}
+
+	providers, ok := o.state.Precipitation[location.Name]
+	if !ok {
+		providers = make(map[string]*precipitation)
+		o.state.Precipitation[location.Name] = providers
+	}
+
+	p, ok := providers[provider]
+	if !ok {
+		p = newPrecipitation()
+		providers[provider] = p
+	}
+
+	if p.add(time.Unix(int64(c.Dt), 0).In(weather.location()), c.Conditions) {
+		if err := o.saveState(); err != nil {
+			_ = level.Error(o.logger).Log("msg", "failed to save state", "err", err)
+		}
+	}
+
+	total := make(map[string]float64, len(precipitationTypes))
+	today := make(map[string]float64, len(precipitationTypes))
+	for typ := range precipitationTypes {
+		total[typ], today[typ] = p.Total[typ], p.Today[typ]
+	}
+	last24h := p.last24h()
+	o.mtx.Unlock()
+
+	for typ := range precipitationTypes {
+		ch <- prometheus.MustNewConstMetric(metricPrecipitationTotalDesc, prometheus.CounterValue, total[typ], location.Name, provider, typ)
+		ch <- prometheus.MustNewConstMetric(metricPrecipitationTodayDesc, prometheus.GaugeValue, today[typ], location.Name, provider, typ)
+		ch <- prometheus.MustNewConstMetric(metricPrecipitation24hDesc, prometheus.GaugeValue, last24h[typ], location.Name, provider, typ)
+	}
+}
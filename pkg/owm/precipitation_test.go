@@ -0,0 +1,81 @@
+package owm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrecipitation(t *testing.T) {
+	p := newPrecipitation()
+	start := time.Date(2022, 1, 1, 22, 0, 0, 0, time.UTC)
+
+	// The first observation only sets where accumulation starts.
+	p.add(start, map[string]float64{"rain_1h": 2})
+	require.Zero(t, p.Total["rain"])
+
+	// Observations every 10 minutes count a sixth of the hourly rain each.
+	for i := 1; i <= 6; i++ {
+		p.add(start.Add(time.Duration(i)*10*time.Minute), map[string]float64{"rain_1h": 3})
+	}
+	require.InDelta(t, 3, p.Total["rain"], 1e-9)
+
+	// A repeated observation is not counted again.
+	p.add(start.Add(time.Hour), map[string]float64{"rain_1h": 3})
+	require.InDelta(t, 3, p.Total["rain"], 1e-9)
+
+	// After a gap only the last hour is known, which here spans midnight, so
+	// only the part since is today's.
+	p.add(start.Add(150*time.Minute), map[string]float64{"rain_1h": 2})
+	require.InDelta(t, 5, p.Total["rain"], 1e-9)
+	require.InDelta(t, 1, p.Today["rain"], 1e-9)
+
+	// An omitted amount is not counted.
+	p.add(start.Add(210*time.Minute), map[string]float64{"snow_1h": 1})
+	require.InDelta(t, 5, p.Total["rain"], 1e-9)
+	require.InDelta(t, 1, p.Total["snow"], 1e-9)
+	require.InDelta(t, 1, p.Today["snow"], 1e-9)
+
+	last24h := p.last24h()
+	require.InDelta(t, 5, last24h["rain"], 1e-9)
+
+	// Amounts older than 24 hours roll off.
+	p.add(start.Add(26*time.Hour), map[string]float64{})
+	last24h = p.last24h()
+	require.InDelta(t, 2, last24h["rain"], 1e-9)
+	require.InDelta(t, 5, p.Total["rain"], 1e-9)
+	require.Zero(t, p.Today["rain"])
+}
+
+func TestPrecipitationState(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state.json")
+	location := Location{Name: "home"}
+	start := time.Now().Add(-time.Hour)
+
+	emit := func(o *OWM, at time.Duration) map[string]float64 {
+		totals := map[string]float64{}
+		for _, m := range collectMetrics(t, func(ch chan<- prometheus.Metric) {
+			o.emitPrecipitation(ch, ProviderOpenWeatherMap, location, &Weather{
+				Current: &Current{Dt: int(start.Add(at).Unix()), Conditions: map[string]float64{"rain_1h": 6}},
+			})
+		}) {
+			if m.desc == metricPrecipitationTotalDesc.String() {
+				totals[m.label("type")] = m.GetCounter().GetValue()
+			}
+		}
+		return totals
+	}
+
+	o := newTestOWM(Config{StateFile: file}, nil)
+	require.NoError(t, o.loadState())
+	emit(o, 0)
+	require.InDelta(t, 1, emit(o, 10*time.Minute)["rain"], 1e-9)
+
+	// The totals are carried over a restart.
+	restarted := newTestOWM(Config{StateFile: file}, nil)
+	require.NoError(t, restarted.loadState())
+	require.InDelta(t, 2, emit(restarted, 20*time.Minute)["rain"], 1e-9)
+}
@@ -0,0 +1,184 @@
+package owm
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// pressureTendencyPeriod is the period the pressure tendency is given
+	// over.
+	pressureTendencyPeriod = 3 * time.Hour
+
+	// pressureTendencyTolerance is how far from the start of the period the
+	// earliest pressure may have been observed.
+	pressureTendencyTolerance = 30 * time.Minute
+)
+
+// pressureTendencies are the WMO terms for the rate of change in pressure
+// over 3 hours, by the largest change in hPa they apply to.  Changes of less
+// than 0.1 hPa are steady.
+var pressureTendencies = []struct {
+	change float64
+	rate   string
+}{
+	{1.5, "slowly"},
+	{3.5, ""},
+	{6, "quickly"},
+	{math.Inf(1), "very_rapidly"},
+}
+
+var (
+	metricPressureChangeDesc = prometheus.NewDesc(
+		"weather_pressure_change_3h",
+		"Change in the current pressure over the last 3 hours (hPa)",
+		[]string{"location", "provider"},
+		nil,
+	)
+
+	metricPressureTendencyDesc = prometheus.NewDesc(
+		"weather_pressure_tendency",
+		"WMO tendency of the current pressure over the last 3 hours",
+		[]string{"location", "provider", "tendency"},
+		nil,
+	)
+
+	metricPressureRapidDropDesc = prometheus.NewDesc(
+		"weather_pressure_rapid_drop",
+		"Whether the current pressure has fallen by at least the rapid drop threshold over the last 3 hours",
+		[]string{"location", "provider"},
+		nil,
+	)
+)
+
+type pressureSample struct {
+	t        time.Time
+	pressure float64
+}
+
+// pressureHistory is the recent current pressure of a location.
+type pressureHistory struct {
+	samples []pressureSample
+}
+
+// add records the pressure observed at t, dropping those no longer needed.
+func (h *pressureHistory) add(t time.Time, pressure float64) {
+	// The same observation is read on every collection.
+	if n := len(h.samples); n > 0 && !t.After(h.samples[n-1].t) {
+		return
+	}
+
+	h.samples = append(h.samples, pressureSample{t, pressure})
+
+	cutoff := t.Add(-pressureTendencyPeriod - pressureTendencyTolerance)
+	i := 0
+	for i < len(h.samples) && h.samples[i].t.Before(cutoff) {
+		i++
+	}
+	h.samples = h.samples[i:]
+}
+
+// change returns the change in pressure over the 3 hours to the latest
+// observation, from the observation nearest to the start of the period.
+func (h *pressureHistory) change() (float64, bool) {
+	if len(h.samples) == 0 {
+		return 0, false
+	}
+
+	latest := h.samples[len(h.samples)-1]
+	start := latest.t.Add(-pressureTendencyPeriod)
+
+	var earliest *pressureSample
+	best := pressureTendencyTolerance
+	for i, s := range h.samples {
+		off := s.t.Sub(start)
+		if off < 0 {
+			off = -off
+		}
+
+		if off <= best {
+			earliest, best = &h.samples[i], off
+		}
+	}
+
+	if earliest == nil {
+		return 0, false
+	}
+
+	return latest.pressure - earliest.pressure, true
+}
+
+// pressureTendency returns the WMO term for a change in pressure over 3
+// hours, eg: falling_quickly.
+func pressureTendency(change float64) string {
+	// Pressure is reported to 0.1 hPa.
+	size := math.Round(math.Abs(change)*10) / 10
+	if size < 0.1 {
+		return "steady"
+	}
+
+	direction := "rising"
+	if change < 0 {
+		direction = "falling"
+	}
+
+	for _, t := range pressureTendencies {
+		if size > t.change {
+			continue
+		}
+
+		if t.rate == "" {
+			return direction
+		}
+
+		return direction + "_" + t.rate
+	}
+
+	return direction
+}
+
+// emitPressureTendency records the current pressure of a location, and sends
+// its tendency once there are 3 hours of observations.
+func (o *OWM) emitPressureTendency(ch chan<- prometheus.Metric, provider string, location Location, weather *Weather) {
+	c := weather.Current
+	if c == nil {
+		return
+	}
+
+	pressure, ok := c.Conditions["pressure"]
+	if !ok {
+		return
+	}
+
+	key := sourceKey{provider, location.Name}
+
+	o.mtx.Lock()
+	if o.pressure == nil {
+		o.pressure = make(map[sourceKey]*pressureHistory)
+	}
+
+	h, ok := o.pressure[key]
+	if !ok {
+		h = &pressureHistory{}
+		o.pressure[key] = h
+	}
+
+	h.add(time.Unix(int64(c.Dt), 0), pressure)
+	change, ok := h.change()
+	o.mtx.Unlock()
+
+	if !ok {
+		return
+	}
+
+	var drop float64
+	if -change >= o.cfg.PressureDropThreshold {
+		drop = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(metricPressureChangeDesc, prometheus.GaugeValue, change, location.Name, provider)
+	ch <- prometheus.MustNewConstMetric(metricPressureTendencyDesc, prometheus.GaugeValue, 1, location.Name, provider, pressureTendency(change))
+	ch <- prometheus.MustNewConstMetric(metricPressureRapidDropDesc, prometheus.GaugeValue, drop, location.Name, provider)
+}
@@ -0,0 +1,81 @@
+package owm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPressureTendency(t *testing.T) {
+	cases := map[float64]string{
+		0:    "steady",
+		0.04: "steady",
+		0.1:  "rising_slowly",
+		-1.5: "falling_slowly",
+		2:    "rising",
+		-3.6: "falling_quickly",
+		6:    "rising_quickly",
+		-6.1: "falling_very_rapidly",
+	}
+
+	for change, tendency := range cases {
+		require.Equal(t, tendency, pressureTendency(change), "%v hPa", change)
+	}
+}
+
+func TestPressureHistory(t *testing.T) {
+	h := &pressureHistory{}
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	h.add(start, 1010)
+	_, ok := h.change()
+	require.False(t, ok)
+
+	for i := 1; i <= 12; i++ {
+		h.add(start.Add(time.Duration(i)*20*time.Minute), 1010-float64(i)*0.5)
+	}
+	// A repeated observation is ignored.
+	h.add(start.Add(4*time.Hour), 900)
+
+	change, ok := h.change()
+	require.True(t, ok)
+	require.InDelta(t, -4.5, change, 1e-9)
+
+	// Only the observations needed are kept.
+	require.Equal(t, start.Add(40*time.Minute), h.samples[0].t)
+
+	// A gap leaves no observation near the start of the period.
+	h.add(start.Add(10*time.Hour), 1000)
+	_, ok = h.change()
+	require.False(t, ok)
+}
+
+func TestEmitPressureTendency(t *testing.T) {
+	o := newTestOWM(Config{PressureTendency: true, PressureDropThreshold: 3.6}, nil)
+	location := Location{Name: "coast"}
+	start := time.Now().Add(-3 * time.Hour)
+
+	emit := func(at time.Duration, pressure float64) map[string]collected {
+		metrics := map[string]collected{}
+		for _, m := range collectMetrics(t, func(ch chan<- prometheus.Metric) {
+			o.emitPressureTendency(ch, "openweathermap", location, &Weather{
+				Current: &Current{Dt: int(start.Add(at).Unix()), Conditions: map[string]float64{"pressure": pressure}},
+			})
+		}) {
+			metrics[m.desc] = m
+		}
+		return metrics
+	}
+
+	require.Empty(t, emit(0, 1012))
+
+	metrics := emit(3*time.Hour, 1007.5)
+	require.Len(t, metrics, 3)
+	require.Equal(t, -4.5, metrics[metricPressureChangeDesc.String()].GetGauge().GetValue())
+	require.Equal(t, 1.0, metrics[metricPressureRapidDropDesc.String()].GetGauge().GetValue())
+
+	tendency := metrics[metricPressureTendencyDesc.String()]
+	require.Equal(t, "falling_quickly", tendency.label("tendency"))
+}
@@ -0,0 +1,154 @@
+package owm
+
+import (
+	"context"
+	"time"
+)
+
+// Provider names, used as the value of the provider label.
+const (
+	ProviderOpenWeatherMap = "openweathermap"
+	ProviderOpenMeteo      = "open-meteo"
+	ProviderNWS            = "nws"
+	ProviderMETNorway      = "met-norway"
+)
+
+// Provider is a source of weather data.  Conditions are keyed by the value of
+// the condition label and given in metric units.
+type Provider interface {
+	// Name returns the value of the provider label.
+	Name() string
+	// Weather returns the current conditions and the hourly and daily
+	// forecasts for a location.
+	Weather(ctx context.Context, location Location) (*Weather, error)
+	// AirQuality returns the current air quality for a location.
+	AirQuality(ctx context.Context, location Location) (*AirQuality, error)
+}
+
+// Weather is the current conditions and forecasts for a location.  Any part a
+// provider does not offer is left empty.
+type Weather struct {
+	Current *Current
+	Hourly  []Forecast
+	Daily   []Forecast
+	Alerts  []Alert
+	Moon    []MoonDay
+	// Timezone is the IANA time zone of the location, eg: Europe/Oslo, when
+	// the provider gives it.
+	Timezone string
+}
+
+// location returns the time zone of the weather, defaulting to the local
+// time zone of the exporter.
+func (w *Weather) location() *time.Location {
+	if w.Timezone != "" {
+		if l, err := time.LoadLocation(w.Timezone); err == nil {
+			return l
+		}
+	}
+
+	return time.Local
+}
+
+// Current is the conditions observed at the unix time Dt.
+type Current struct {
+	Dt         int
+	Sunrise    int
+	Sunset     int
+	Conditions map[string]float64
+	Summary    []Summary
+}
+
+// Summary is a short description of the weather, eg: Rain, light rain.
+type Summary struct {
+	Main        string
+	Description string
+}
+
+// Forecast is the conditions forecast for the unix time Dt.
+type Forecast struct {
+	Dt         int
+	Conditions map[string]float64
+}
+
+// MoonDay is the moon on the day starting at the unix time Dt.  Moonrise and
+// Moonset are unix times, zero on days without one.  Phase is 0 and 1 at new
+// moon and 0.5 at full moon.
+type MoonDay struct {
+	Dt       int
+	Moonrise int
+	Moonset  int
+	Phase    float64
+}
+
+// Alert is a weather warning in effect for a location.  Severity, Urgency and
+// Certainty take the CAP values, eg: Severe, Immediate, Likely, and are Unknown
+// when the provider does not give them.
+type Alert struct {
+	Event     string
+	Severity  string
+	Urgency   string
+	Certainty string
+	// Onset and Expires are unix times.
+	Onset   int
+	Expires int
+}
+
+// AirQuality is the air quality index on the provider's own scale, and the
+// concentrations of pollutants and pollen keyed by component.
+type AirQuality struct {
+	AQI        float64
+	Components map[string]float64
+}
+
+// knownProviders are the providers that can be selected for a location.
+var knownProviders = map[string]bool{
+	ProviderOpenWeatherMap: true,
+	ProviderOpenMeteo:      true,
+	ProviderNWS:            true,
+	ProviderMETNorway:      true,
+}
+
+// userAgentProviders are the providers whose terms require the exporter to
+// identify itself with a User-Agent.
+var userAgentProviders = map[string]bool{
+	ProviderNWS:       true,
+	ProviderMETNorway: true,
+}
+
+func (o *OWM) newProviders() map[string]Provider {
+	providers := []Provider{
+		&owmProvider{o: o},
+		&openMeteoProvider{o: o},
+		newNWSProvider(o),
+		newMETNorwayProvider(o),
+	}
+
+	byName := make(map[string]Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+
+	return byName
+}
+
+// providers returns the names of the providers selected for a location,
+// defaulting to OpenWeatherMap.
+func (l Location) providers() []string {
+	if len(l.Providers) == 0 {
+		return []string{ProviderOpenWeatherMap}
+	}
+
+	return l.Providers
+}
+
+// hasProvider returns whether the location is collected from the provider.
+func (l Location) hasProvider(name string) bool {
+	for _, p := range l.providers() {
+		if p == name {
+			return true
+		}
+	}
+
+	return false
+}
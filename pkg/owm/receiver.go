@@ -0,0 +1,181 @@
+package owm
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ecowittPath = "/ecowitt"
+	ambientPath = "/ambient"
+
+	// observationTTL is how long a pushed observation is exported after it
+	// was received.
+	observationTTL = 15 * time.Minute
+)
+
+var metricWeatherObservedDesc = prometheus.NewDesc(
+	"weather_observed",
+	"Weather condition observed by a local station",
+	[]string{"station", "condition"},
+	nil,
+)
+
+// observedField maps a field of the Ecowitt and Ambient Weather custom server
+// protocols to a condition, converting it from imperial to metric units.
+type observedField struct {
+	condition string
+	convert   func(float64) float64
+}
+
+var observedFields = map[string]observedField{
+	"tempf":          {"temp", fahrenheitToCelsius},
+	"tempinf":        {"indoor_temp", fahrenheitToCelsius},
+	"dewptf":         {"dew_point", fahrenheitToCelsius},
+	"humidity":       {"humidity", nil},
+	"humidityin":     {"indoor_humidity", nil},
+	"baromrelin":     {"pressure", inHgToHPa},
+	"baromabsin":     {"pressure_absolute", inHgToHPa},
+	"windspeedmph":   {"wind_speed", mphToMetresPerSecond},
+	"windgustmph":    {"wind_gust", mphToMetresPerSecond},
+	"maxdailygust":   {"wind_gust_daily_max", mphToMetresPerSecond},
+	"winddir":        {"wind_degree", nil},
+	"solarradiation": {"solar_radiation", nil},
+	"uv":             {"uvi", nil},
+	"rainratein":     {"rain_rate", inchesToMillimetres},
+	"hourlyrainin":   {"rain_1h", inchesToMillimetres},
+	"eventrainin":    {"rain_event", inchesToMillimetres},
+	"dailyrainin":    {"rain_today", inchesToMillimetres},
+	"weeklyrainin":   {"rain_week", inchesToMillimetres},
+	"monthlyrainin":  {"rain_month", inchesToMillimetres},
+	"yearlyrainin":   {"rain_year", inchesToMillimetres},
+	"totalrainin":    {"rain_total", inchesToMillimetres},
+}
+
+// observation is the conditions reported by a local station at Time.
+type observation struct {
+	Time       time.Time
+	Conditions map[string]float64
+}
+
+// receiverHandler accepts observations pushed by local stations, either as a
+// form POST from an Ecowitt station or as a query string GET from an Ambient
+// Weather station.
+func (o *OWM) receiverHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key := r.Form.Get("PASSKEY")
+	if key == "" {
+		key = r.Form.Get("MAC")
+	}
+
+	station, ok := o.localStation(key)
+	if !ok {
+		_ = level.Warn(o.logger).Log("msg", "observation from unknown station", "key", key)
+		http.Error(w, "unknown station", http.StatusNotFound)
+		return
+	}
+
+	conditions := parseObservation(r.Form)
+
+	o.mtx.Lock()
+	o.observed[station.Name] = observation{Time: time.Now(), Conditions: conditions}
+	o.mtx.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (o *OWM) localStation(key string) (LocalStation, bool) {
+	if key == "" {
+		return LocalStation{}, false
+	}
+
+	for _, station := range o.cfg.LocalStations {
+		if strings.EqualFold(station.Key, key) {
+			return station, true
+		}
+	}
+
+	return LocalStation{}, false
+}
+
+// parseObservation returns the conditions in the pushed fields.  Battery
+// fields are passed through as reported, since their meaning varies by
+// sensor.
+func parseObservation(form map[string][]string) map[string]float64 {
+	conditions := make(map[string]float64)
+
+	for field, values := range form {
+		if len(values) == 0 {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			continue
+		}
+
+		if strings.Contains(field, "batt") {
+			conditions["battery_"+field] = value
+			continue
+		}
+
+		f, ok := observedFields[field]
+		if !ok {
+			continue
+		}
+
+		if f.convert != nil {
+			value = f.convert(value)
+		}
+		conditions[f.condition] = value
+	}
+
+	return conditions
+}
+
+// collectObserved sends the recent observations of the local stations.
+func (o *OWM) collectObserved(ch chan<- prometheus.Metric) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	for station, obs := range o.observed {
+		if time.Since(obs.Time) > observationTTL {
+			continue
+		}
+
+		for condition, value := range obs.Conditions {
+			ch <- prometheus.MustNewConstMetric(
+				metricWeatherObservedDesc,
+				prometheus.GaugeValue,
+				value,
+				station,
+				condition,
+			)
+		}
+	}
+}
+
+func fahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
+
+func inHgToHPa(in float64) float64 {
+	return in * 33.8639
+}
+
+func mphToMetresPerSecond(mph float64) float64 {
+	return mph * 0.44704
+}
+
+func inchesToMillimetres(in float64) float64 {
+	return in * 25.4
+}
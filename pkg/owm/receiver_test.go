@@ -0,0 +1,45 @@
+package owm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiverHandler(t *testing.T) {
+	o := newTestOWM(Config{LocalStations: []LocalStation{
+		{Name: "garden", Key: "ABCDEF0123456789"},
+		{Name: "roof", Key: "00:0E:C6:20:0F:7B"},
+	}}, nil)
+
+	// Ecowitt stations POST a form.
+	body := "PASSKEY=ABCDEF0123456789&stationtype=GW1000&tempf=68.0&humidity=50&baromrelin=29.92&windspeedmph=10&dailyrainin=0.5&wh65batt=0"
+	req := httptest.NewRequest(http.MethodPost, ecowittPath, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	o.receiverHandler(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	garden := o.observed["garden"].Conditions
+	require.InDelta(t, 20, garden["temp"], 0.01)
+	require.InDelta(t, 50, garden["humidity"], 0.01)
+	require.InDelta(t, 1013.2, garden["pressure"], 0.1)
+	require.InDelta(t, 4.47, garden["wind_speed"], 0.01)
+	require.InDelta(t, 12.7, garden["rain_today"], 0.01)
+	require.Equal(t, 0.0, garden["battery_wh65batt"])
+	require.NotContains(t, garden, "stationtype")
+
+	// Ambient Weather stations GET with a query string.
+	rec = httptest.NewRecorder()
+	o.receiverHandler(rec, httptest.NewRequest(http.MethodGet, ambientPath+"?&MAC=00:0E:C6:20:0F:7B&tempf=32&battout=1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.InDelta(t, 0, o.observed["roof"].Conditions["temp"], 0.01)
+	require.Equal(t, 1.0, o.observed["roof"].Conditions["battery_battout"])
+
+	rec = httptest.NewRecorder()
+	o.receiverHandler(rec, httptest.NewRequest(http.MethodGet, ambientPath+"?PASSKEY=nope&tempf=32", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
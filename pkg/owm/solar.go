@@ -0,0 +1,292 @@
+package owm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	solarURL = "https://api.openweathermap.org/energy/1.0/solar/data?lat=%f&lon=%f&date=%s&appid=%s"
+
+	// solarConstant is the mean extraterrestrial irradiance in W/m².
+	solarConstant = 1361.0
+	// groundAlbedo is the fraction of irradiance reflected by the ground onto
+	// the panels.
+	groundAlbedo = 0.2
+
+	// solarCacheTTL is how long the irradiance read for a date is used before
+	// it is requested again.
+	solarCacheTTL = time.Hour
+)
+
+var (
+	metricSolarIrradianceDesc = prometheus.NewDesc(
+		"solar_irradiance_forecast",
+		"Solar irradiance forecast (W/m²): (ghi|dni|dhi)",
+		[]string{"location", "provider", "component", "source", "future_hours"},
+		nil,
+	)
+
+	metricSolarPVPowerDesc = prometheus.NewDesc(
+		"solar_pv_power_forecast_watts",
+		"Expected photovoltaic output",
+		[]string{"location", "provider", "future_hours"},
+		nil,
+	)
+)
+
+// irradiance holds the global horizontal, direct normal and diffuse horizontal
+// components of solar irradiance in W/m².
+type irradiance struct {
+	GHI float64 `json:"ghi"`
+	DNI float64 `json:"dni"`
+	DHI float64 `json:"dhi"`
+}
+
+type solarResponse struct {
+	Date       string `json:"date"`
+	Tz         string `json:"tz"`
+	Irradiance struct {
+		Hourly []struct {
+			Hour      int        `json:"hour"`
+			CloudySky irradiance `json:"cloudy_sky"`
+		} `json:"hourly"`
+	} `json:"irradiance"`
+}
+
+// solarKey identifies the irradiance read for a location on a date.
+type solarKey struct {
+	location string
+	date     string
+}
+
+// solarCache is the hourly irradiance read for a location on a date, keyed by
+// the unix time of the start of each hour.
+type solarCache struct {
+	expires time.Time
+	hours   map[int64]irradiance
+}
+
+// collectSolar sends the irradiance and photovoltaic output forecast for the
+// forecast hours of a location.  Irradiance is read from the Solar Irradiance
+// API when enabled, and otherwise estimated from the cloud cover forecast and
+// the position of the sun.
+func (o *OWM) collectSolar(ctx context.Context, ch chan<- prometheus.Metric, provider string, location Location, hours []Forecast) {
+	ctx, span := o.tracer.Start(ctx, "collectSolar")
+	defer span.End()
+
+	var measured map[int64]irradiance
+	if o.cfg.SolarAPI {
+		var err error
+		measured, err = o.solarIrradiance(ctx, location, hours)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			_ = level.Warn(o.logger).Log("msg", "solar irradiance unavailable, estimating", "location", location.Name, "err", err)
+		}
+	}
+
+	for _, hour := range hours {
+		t := time.Unix(int64(hour.Dt), 0)
+		future := futureHours(hour.Dt)
+
+		source := "api"
+		irr, ok := measured[t.Truncate(time.Hour).Unix()]
+		if !ok {
+			source = "estimate"
+			irr = estimateIrradiance(t, location.Latitude, location.Longitude, hour.Conditions["clouds"])
+		}
+
+		components := map[string]float64{
+			"ghi": irr.GHI,
+			"dni": irr.DNI,
+			"dhi": irr.DHI,
+		}
+
+		for component, value := range components {
+			ch <- prometheus.MustNewConstMetric(
+				metricSolarIrradianceDesc,
+				prometheus.GaugeValue,
+				value,
+				location.Name,
+				provider,
+				component,
+				source,
+				future,
+			)
+		}
+
+		poa := planeOfArray(t, location.Latitude, location.Longitude, irr, location.PV.Tilt, location.PV.Azimuth)
+
+		ch <- prometheus.MustNewConstMetric(
+			metricSolarPVPowerDesc,
+			prometheus.GaugeValue,
+			location.PV.power(poa),
+			location.Name,
+			provider,
+			future,
+		)
+	}
+}
+
+// solarIrradiance reads the hourly cloudy sky irradiance from the Solar
+// Irradiance API for the days covered by hours, keyed by the unix time of the
+// start of each hour.  Each day is read from the cache until it expires.
+func (o *OWM) solarIrradiance(ctx context.Context, location Location, hours []Forecast) (map[int64]irradiance, error) {
+	dates := make(map[string]struct{})
+	for _, hour := range hours {
+		dates[time.Unix(int64(hour.Dt), 0).UTC().Format("2006-01-02")] = struct{}{}
+	}
+
+	now := time.Now()
+	measured := make(map[int64]irradiance)
+	for date := range dates {
+		key := solarKey{location.Name, date}
+
+		o.mtx.Lock()
+		cached, ok := o.solar[key]
+		o.mtx.Unlock()
+
+		if !ok || !now.Before(cached.expires) {
+			day, err := o.solarDay(ctx, location, date)
+			if err != nil {
+				return nil, err
+			}
+
+			cached = &solarCache{expires: now.Add(solarCacheTTL), hours: day}
+
+			o.mtx.Lock()
+			if o.solar == nil {
+				o.solar = make(map[solarKey]*solarCache)
+			}
+			o.solar[key] = cached
+			o.mtx.Unlock()
+		}
+
+		for t, irr := range cached.hours {
+			measured[t] = irr
+		}
+	}
+
+	// Dates no longer forecast are not read again.
+	o.mtx.Lock()
+	for key := range o.solar {
+		if _, ok := dates[key.date]; key.location == location.Name && !ok {
+			delete(o.solar, key)
+		}
+	}
+	o.mtx.Unlock()
+
+	return measured, nil
+}
+
+// solarDay reads the hourly cloudy sky irradiance for a location on a date
+// from the Solar Irradiance API.
+func (o *OWM) solarDay(ctx context.Context, location Location, date string) (map[int64]irradiance, error) {
+	url := fmt.Sprintf(solarURL, location.Latitude, location.Longitude, date, o.cfg.APIKey)
+
+	var resp solarResponse
+	if err := o.getJSON(ctx, url, &resp); err != nil {
+		return nil, err
+	}
+
+	midnight, err := time.Parse("2006-01-02-07:00", resp.Date+resp.Tz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse solar date: %w", err)
+	}
+
+	day := make(map[int64]irradiance, len(resp.Irradiance.Hourly))
+	for _, h := range resp.Irradiance.Hourly {
+		day[midnight.Add(time.Duration(h.Hour)*time.Hour).Unix()] = h.CloudySky
+	}
+
+	return day, nil
+}
+
+// getJSON decodes the JSON document at url into v.
+func (o *OWM) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// estimateIrradiance estimates the irradiance at t from the sun elevation and
+// the cloud cover percentage, using the Haurwitz clear sky model, the Kasten
+// and Czeplak cloud cover correction and the Erbs diffuse fraction.
+func estimateIrradiance(t time.Time, latitude, longitude, clouds float64) irradiance {
+	elevation, _ := sunPosition(t, latitude, longitude)
+	if elevation <= 0 {
+		return irradiance{}
+	}
+
+	cosZenith := math.Sin(elevation * deg2rad)
+
+	clearSky := 1098 * cosZenith * math.Exp(-0.057/cosZenith)
+	ghi := clearSky * (1 - 0.75*math.Pow(clouds/100, 3.4))
+
+	extraterrestrial := solarConstant * (1 + 0.033*math.Cos(2*math.Pi*float64(t.UTC().YearDay())/365))
+	kt := ghi / (extraterrestrial * cosZenith)
+
+	var diffuse float64
+	switch {
+	case kt <= 0.22:
+		diffuse = 1 - 0.09*kt
+	case kt <= 0.8:
+		diffuse = 0.9511 - 0.1604*kt + 4.388*kt*kt - 16.638*math.Pow(kt, 3) + 12.336*math.Pow(kt, 4)
+	default:
+		diffuse = 0.165
+	}
+
+	dhi := diffuse * ghi
+
+	return irradiance{
+		GHI: ghi,
+		DNI: (ghi - dhi) / cosZenith,
+		DHI: dhi,
+	}
+}
+
+// planeOfArray returns the irradiance in W/m² reaching a panel with the given
+// tilt and azimuth, using the isotropic sky model.
+func planeOfArray(t time.Time, latitude, longitude float64, irr irradiance, tilt, azimuth float64) float64 {
+	elevation, sunAzimuth := sunPosition(t, latitude, longitude)
+	if elevation <= 0 {
+		return 0
+	}
+
+	zenith := (90 - elevation) * deg2rad
+	beta := tilt * deg2rad
+
+	cosIncidence := math.Cos(zenith)*math.Cos(beta) + math.Sin(zenith)*math.Sin(beta)*math.Cos((sunAzimuth-azimuth)*deg2rad)
+
+	return irr.DNI*math.Max(cosIncidence, 0) +
+		irr.DHI*(1+math.Cos(beta))/2 +
+		irr.GHI*groundAlbedo*(1-math.Cos(beta))/2
+}
+
+// power returns the expected output in watts of the array for the plane of
+// array irradiance poa.
+func (p *PV) power(poa float64) float64 {
+	return p.KWp * poa * (1 - p.Losses/100)
+}
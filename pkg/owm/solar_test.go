@@ -0,0 +1,47 @@
+package owm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolarIrradianceCache(t *testing.T) {
+	requests := make(map[string]int)
+	o := newTestOWM(Config{SolarAPI: true}, func(req *http.Request) (*http.Response, error) {
+		date := req.URL.Query().Get("date")
+		requests[date]++
+
+		return jsonResponse(fmt.Sprintf(`{"date":%q,"tz":"+00:00","irradiance":{"hourly":[
+			{"hour":12,"cloudy_sky":{"ghi":500,"dni":600,"dhi":100}}]}}`, date)), nil
+	})
+
+	location := Location{Name: "home", Latitude: 51.5, Longitude: -0.1}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	hours := []Forecast{
+		{Dt: int(today.Add(12 * time.Hour).Unix())},
+		{Dt: int(today.Add(36 * time.Hour).Unix())},
+	}
+
+	for i := 0; i < 3; i++ {
+		measured, err := o.solarIrradiance(context.Background(), location, hours)
+		require.NoError(t, err)
+		require.Equal(t, 500.0, measured[today.Add(12*time.Hour).Unix()].GHI)
+		require.Equal(t, 600.0, measured[today.Add(36*time.Hour).Unix()].DNI)
+	}
+
+	// Each date is read once while cached.
+	require.Equal(t, map[string]int{
+		today.Format("2006-01-02"):                     1,
+		today.Add(24 * time.Hour).Format("2006-01-02"): 1,
+	}, requests)
+
+	// Dates no longer forecast are dropped.
+	_, err := o.solarIrradiance(context.Background(), location, hours[1:])
+	require.NoError(t, err)
+	require.Len(t, o.solar, 1)
+}
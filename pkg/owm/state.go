@@ -0,0 +1,55 @@
+package owm
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// state is the accumulated data kept in the state file, so that it survives
+// restarts.
+type state struct {
+	GDD map[string]*gddState `json:"gdd,omitempty"`
+	FWI map[string]*fwiState `json:"fwi,omitempty"`
+	// Precipitation is accumulated by location and provider.
+	Precipitation map[string]map[string]*precipitation `json:"precipitation,omitempty"`
+}
+
+// loadState reads the state file, if one is configured and exists.
+func (o *OWM) loadState() error {
+	o.state = &state{}
+
+	if o.cfg.StateFile == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(o.cfg.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, o.state)
+}
+
+// saveState writes the state file, if one is configured, replacing it
+// atomically.  It must be called with o.mtx held.
+func (o *OWM) saveState() error {
+	if o.cfg.StateFile == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(o.state)
+	if err != nil {
+		return err
+	}
+
+	tmp := o.cfg.StateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, o.cfg.StateFile)
+}
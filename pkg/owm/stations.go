@@ -0,0 +1,382 @@
+package owm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	stationsURL     = "https://api.openweathermap.org/data/3.0/stations?appid=%s"
+	measurementsURL = "https://api.openweathermap.org/data/3.0/measurements?appid=%s"
+
+	stationPushPrefix = "/stations/"
+)
+
+// errNoMeasurements is returned when a station has no measurements to upload.
+var errNoMeasurements = errors.New("no measurements")
+
+// stationMeasurements are the measurements accepted by the Stations API.
+var stationMeasurements = map[string]bool{
+	"temperature":         true,
+	"wind_speed":          true,
+	"wind_gust":           true,
+	"wind_deg":            true,
+	"pressure":            true,
+	"humidity":            true,
+	"rain_1h":             true,
+	"rain_6h":             true,
+	"rain_24h":            true,
+	"snow_1h":             true,
+	"snow_6h":             true,
+	"snow_24h":            true,
+	"dew_point":           true,
+	"humidex":             true,
+	"heat_index":          true,
+	"visibility_distance": true,
+}
+
+type owmStation struct {
+	ID         string  `json:"id,omitempty"`
+	ExternalID string  `json:"external_id"`
+	Name       string  `json:"name"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Altitude   float64 `json:"altitude"`
+}
+
+type promQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// runStations uploads the measurements of the configured stations every
+// station interval until ctx is done.
+func (o *OWM) runStations(ctx context.Context) {
+	ticker := time.NewTicker(o.cfg.StationInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, station := range o.cfg.Stations {
+			// An upload is abandoned by the next.
+			uploadCtx, cancel := context.WithTimeout(ctx, o.cfg.StationInterval)
+			o.uploadStation(uploadCtx, station)
+			cancel()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// uploadStation sends the current measurements of a station, registering the
+// station with the Stations API as needed.
+func (o *OWM) uploadStation(ctx context.Context, station Station) {
+	ctx, span := o.tracer.Start(ctx, "uploadStation")
+	defer span.End()
+
+	err := o.sendStationMeasurements(ctx, station)
+	if errors.Is(err, errNoMeasurements) {
+		// Nothing was uploaded, so the last upload is left to go stale.
+		_ = level.Debug(o.logger).Log("msg", "no station measurements to upload", "station", station.ExternalID)
+		return
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		_ = level.Error(o.logger).Log("msg", "failed to upload station measurements", "station", station.ExternalID, "err", err)
+		o.stationUploads.WithLabelValues(station.ExternalID, "failure").Inc()
+		return
+	}
+
+	o.stationUploads.WithLabelValues(station.ExternalID, "success").Inc()
+	o.stationLastUpload.WithLabelValues(station.ExternalID).SetToCurrentTime()
+}
+
+// sendStationMeasurements uploads the measurements of a station.  Queries that
+// fail are skipped, and the readings pushed to the exporter are kept for the
+// next upload unless this one succeeds.
+func (o *OWM) sendStationMeasurements(ctx context.Context, station Station) (err error) {
+	measurements := make(map[string]float64)
+
+	for measurement, query := range station.Queries {
+		value, err := o.queryPrometheus(ctx, query)
+		if err != nil {
+			_ = level.Warn(o.logger).Log("msg", "station query failed", "station", station.ExternalID, "measurement", measurement, "err", err)
+			continue
+		}
+		measurements[measurement] = value
+	}
+
+	o.mtx.Lock()
+	pushed := o.stationPushed[station.ExternalID]
+	delete(o.stationPushed, station.ExternalID)
+	o.mtx.Unlock()
+
+	for measurement, value := range pushed {
+		measurements[measurement] = value
+	}
+
+	defer func() {
+		if err != nil && len(pushed) > 0 {
+			o.restorePushed(station.ExternalID, pushed)
+		}
+	}()
+
+	if len(measurements) == 0 {
+		return errNoMeasurements
+	}
+
+	id, err := o.stationID(ctx, station)
+	if err != nil {
+		return err
+	}
+
+	body := make(map[string]interface{}, len(measurements)+2)
+	for measurement, value := range measurements {
+		body[measurement] = value
+	}
+	body["station_id"] = id
+	body["dt"] = time.Now().Unix()
+
+	resp, err := o.postJSON(ctx, fmt.Sprintf(measurementsURL, o.cfg.APIKey), []interface{}{body})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// restorePushed returns readings that were not uploaded to those pushed for a
+// station, unless a newer reading has been pushed since.
+func (o *OWM) restorePushed(externalID string, readings map[string]float64) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	pushed, ok := o.stationPushed[externalID]
+	if !ok {
+		pushed = make(map[string]float64, len(readings))
+		o.stationPushed[externalID] = pushed
+	}
+
+	for measurement, value := range readings {
+		if _, ok := pushed[measurement]; !ok {
+			pushed[measurement] = value
+		}
+	}
+}
+
+// stationID returns the Stations API ID of station, reusing a station
+// already registered under the same external ID or registering a new one.
+func (o *OWM) stationID(ctx context.Context, station Station) (string, error) {
+	// The Stations API is called without the lock held, so that a slow
+	// response does not hold up collection.
+	o.mtx.Lock()
+	id, ok := o.stationIDs[station.ExternalID]
+	listed := o.stationIDs != nil
+	o.mtx.Unlock()
+
+	if ok {
+		return id, nil
+	}
+
+	if !listed {
+		var existing []owmStation
+		if err := o.getJSON(ctx, fmt.Sprintf(stationsURL, o.cfg.APIKey), &existing); err != nil {
+			return "", err
+		}
+
+		ids := make(map[string]string, len(existing))
+		for _, s := range existing {
+			ids[s.ExternalID] = s.ID
+		}
+
+		o.mtx.Lock()
+		if o.stationIDs == nil {
+			o.stationIDs = ids
+		}
+		id, ok = o.stationIDs[station.ExternalID]
+		o.mtx.Unlock()
+
+		if ok {
+			return id, nil
+		}
+	}
+
+	resp, err := o.postJSON(ctx, fmt.Sprintf(stationsURL, o.cfg.APIKey), owmStation{
+		ExternalID: station.ExternalID,
+		Name:       station.Name,
+		Latitude:   station.Latitude,
+		Longitude:  station.Longitude,
+		Altitude:   station.Altitude,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s registering station", resp.Status)
+	}
+
+	// The API answers a registration with the ID in an upper case field.
+	var created struct {
+		ID string `json:"ID"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", err
+	}
+
+	_ = level.Info(o.logger).Log("msg", "registered station", "station", station.ExternalID, "id", created.ID)
+
+	o.mtx.Lock()
+	o.stationIDs[station.ExternalID] = created.ID
+	o.mtx.Unlock()
+
+	return created.ID, nil
+}
+
+// queryPrometheus returns the value of the first sample of an instant query.
+func (o *OWM) queryPrometheus(ctx context.Context, query string) (float64, error) {
+	u := strings.TrimSuffix(o.cfg.PrometheusURL, "/") + "/api/v1/query?" + url.Values{"query": []string{query}}.Encode()
+
+	var resp promQueryResponse
+	if err := o.getJSON(ctx, u, &resp); err != nil {
+		return 0, err
+	}
+
+	if resp.Status != "success" {
+		return 0, fmt.Errorf("query failed: %s", resp.Error)
+	}
+
+	var sample []interface{}
+	switch resp.Data.ResultType {
+	case "vector":
+		var vector []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(resp.Data.Result, &vector); err != nil {
+			return 0, err
+		}
+		if len(vector) == 0 {
+			return 0, fmt.Errorf("query returned no samples")
+		}
+		sample = vector[0].Value
+	case "scalar":
+		if err := json.Unmarshal(resp.Data.Result, &sample); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("unsupported result type %q", resp.Data.ResultType)
+	}
+
+	if len(sample) != 2 {
+		return 0, fmt.Errorf("malformed sample")
+	}
+
+	value, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("malformed sample value")
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// stationPushHandler accepts readings for a station as a JSON object of
+// measurements at /stations/<external_id>, to be sent with its next upload.
+func (o *OWM) stationPushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	externalID := strings.TrimPrefix(r.URL.Path, stationPushPrefix)
+
+	known := false
+	for _, station := range o.cfg.Stations {
+		if station.ExternalID == externalID {
+			known = true
+			break
+		}
+	}
+	if !known {
+		http.Error(w, "unknown station", http.StatusNotFound)
+		return
+	}
+
+	readings := make(map[string]float64)
+	if err := json.NewDecoder(r.Body).Decode(&readings); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for measurement := range readings {
+		if !stationMeasurements[measurement] {
+			http.Error(w, fmt.Sprintf("unknown measurement %q", measurement), http.StatusBadRequest)
+			return
+		}
+	}
+
+	o.mtx.Lock()
+	if o.stationPushed[externalID] == nil {
+		o.stationPushed[externalID] = make(map[string]float64)
+	}
+	for measurement, value := range readings {
+		o.stationPushed[externalID][measurement] = value
+	}
+	o.mtx.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (o *OWM) postJSON(ctx context.Context, url string, v interface{}) (*http.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return o.client.Do(req)
+}
+
+func newStationMetrics() (*prometheus.CounterVec, *prometheus.GaugeVec) {
+	uploads := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "station_uploads_total",
+		Help: "Station measurement uploads to the Stations API by result",
+	}, []string{"station", "result"})
+
+	lastUpload := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "station_last_upload_timestamp_seconds",
+		Help: "Time of the last successful station measurement upload",
+	}, []string{"station"})
+
+	return uploads, lastUpload
+}
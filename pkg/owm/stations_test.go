@@ -0,0 +1,137 @@
+package owm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadStation(t *testing.T) {
+	station := Station{
+		ExternalID: "backyard",
+		Name:       "Backyard",
+		Queries:    map[string]string{"temperature": `sensor_temperature{room="outside"}`},
+	}
+
+	var uploaded []map[string]interface{}
+	o := newTestOWM(Config{PrometheusURL: "http://prometheus:9090", Stations: []Station{station}}, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.URL.Host == "prometheus:9090":
+			require.Equal(t, station.Queries["temperature"], req.URL.Query().Get("query"))
+			return jsonResponse(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"21.5"]}]}}`), nil
+		case strings.HasSuffix(req.URL.Path, "/stations") && req.Method == http.MethodGet:
+			return jsonResponse(`[]`), nil
+		case strings.HasSuffix(req.URL.Path, "/stations"):
+			resp := jsonResponse(`{"ID":"abc123","external_id":"backyard"}`)
+			resp.StatusCode = http.StatusCreated
+			return resp, nil
+		case strings.HasSuffix(req.URL.Path, "/measurements"):
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&uploaded))
+			resp := jsonResponse(``)
+			resp.StatusCode = http.StatusNoContent
+			return resp, nil
+		}
+
+		t.Fatalf("unexpected request %s", req.URL)
+		return nil, nil
+	})
+
+	rec := httptest.NewRecorder()
+	o.stationPushHandler(rec, httptest.NewRequest(http.MethodPost, "/stations/backyard", strings.NewReader(`{"humidity":60}`)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	o.uploadStation(context.Background(), station)
+
+	require.Len(t, uploaded, 1)
+	require.Equal(t, "abc123", uploaded[0]["station_id"])
+	require.Equal(t, 21.5, uploaded[0]["temperature"])
+	require.Equal(t, 60.0, uploaded[0]["humidity"])
+
+	m := &dto.Metric{}
+	require.NoError(t, o.stationUploads.WithLabelValues("backyard", "success").Write(m))
+	require.Equal(t, 1.0, m.GetCounter().GetValue())
+
+	rec = httptest.NewRecorder()
+	o.stationPushHandler(rec, httptest.NewRequest(http.MethodPost, "/stations/unknown", strings.NewReader(`{}`)))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestUploadStationFailures(t *testing.T) {
+	station := Station{
+		ExternalID: "backyard",
+		Queries:    map[string]string{"temperature": `sensor_temperature{room="outside"}`},
+	}
+
+	status := http.StatusInternalServerError
+	var uploaded []map[string]interface{}
+	o := newTestOWM(Config{PrometheusURL: "http://prometheus:9090", Stations: []Station{station}}, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.URL.Host == "prometheus:9090":
+			resp := jsonResponse(`{"status":"error","error":"unavailable"}`)
+			resp.StatusCode = http.StatusServiceUnavailable
+			return resp, nil
+		case strings.HasSuffix(req.URL.Path, "/stations"):
+			return jsonResponse(`[{"id":"abc123","external_id":"backyard"}]`), nil
+		case strings.HasSuffix(req.URL.Path, "/measurements"):
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&uploaded))
+			resp := jsonResponse(``)
+			resp.StatusCode = status
+			return resp, nil
+		}
+
+		t.Fatalf("unexpected request %s", req.URL)
+		return nil, nil
+	})
+
+	rec := httptest.NewRecorder()
+	o.stationPushHandler(rec, httptest.NewRequest(http.MethodPost, "/stations/backyard", strings.NewReader(`{"humidity":60}`)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	// The failed query is skipped, and the pushed readings are kept when the
+	// upload fails.
+	o.uploadStation(context.Background(), station)
+	require.Len(t, uploaded, 1)
+	require.Equal(t, map[string]interface{}{"station_id": "abc123", "humidity": 60.0, "dt": uploaded[0]["dt"]}, uploaded[0])
+	require.Equal(t, map[string]float64{"humidity": 60}, o.stationPushed["backyard"])
+
+	status = http.StatusNoContent
+	o.uploadStation(context.Background(), station)
+	require.Equal(t, 60.0, uploaded[0]["humidity"])
+	require.Empty(t, o.stationPushed["backyard"])
+}
+
+func TestUploadStationNoMeasurements(t *testing.T) {
+	station := Station{
+		ExternalID: "backyard",
+		Queries:    map[string]string{"temperature": `sensor_temperature{room="outside"}`},
+	}
+
+	o := newTestOWM(Config{PrometheusURL: "http://prometheus:9090", Stations: []Station{station}}, func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "prometheus:9090", req.URL.Host)
+
+		resp := jsonResponse(`{"status":"error","error":"unavailable"}`)
+		resp.StatusCode = http.StatusServiceUnavailable
+		return resp, nil
+	})
+
+	// Every query fails and nothing was pushed, so nothing is uploaded.
+	require.ErrorIs(t, o.sendStationMeasurements(context.Background(), station), errNoMeasurements)
+
+	o.uploadStation(context.Background(), station)
+
+	for _, result := range []string{"success", "failure"} {
+		m := &dto.Metric{}
+		require.NoError(t, o.stationUploads.WithLabelValues("backyard", result).Write(m))
+		require.Zero(t, m.GetCounter().GetValue())
+	}
+
+	m := &dto.Metric{}
+	require.NoError(t, o.stationLastUpload.WithLabelValues("backyard").Write(m))
+	require.Zero(t, m.GetGauge().GetValue())
+}
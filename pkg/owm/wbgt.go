@@ -0,0 +1,175 @@
+package owm
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	stefanBoltzmann = 5.67e-8
+	// globeEmissivity and globeDiameter in metres are those of the standard
+	// black globe.
+	globeEmissivity = 0.95
+	globeDiameter   = 0.15
+
+	// minGlobeWind is the lowest wind speed in m/s the globe temperature is
+	// estimated for, as it is undefined in still air.
+	minGlobeWind = 0.5
+)
+
+// defaultWBGTThresholds are the WBGT in °C of the US military heat flags:
+// green, yellow, red and black.
+var defaultWBGTThresholds = []float64{27.8, 29.4, 31.1, 32.2}
+
+var (
+	metricWBGTCategoryDesc = prometheus.NewDesc(
+		"weather_wbgt_category",
+		"Heat stress category of the current wet-bulb globe temperature, the number of thresholds reached",
+		[]string{"location", "provider"},
+		nil,
+	)
+
+	metricForecastWBGTCategoryDesc = prometheus.NewDesc(
+		"weather_forecast_wbgt_category",
+		"Heat stress category of the forecast wet-bulb globe temperature, the number of thresholds reached",
+		[]string{"location", "provider", "future_hours"},
+		nil,
+	)
+)
+
+// wetBulb returns the psychrometric wet bulb temperature in °C for the
+// temperature in °C and relative humidity in percent, by Stull's formula.
+func wetBulb(t, rh float64) float64 {
+	return t*math.Atan(0.151977*math.Sqrt(rh+8.313659)) +
+		math.Atan(t+rh) - math.Atan(rh-1.676331) +
+		0.00391838*math.Pow(rh, 1.5)*math.Atan(0.023101*rh) - 4.686035
+}
+
+// globeTemperature returns the black globe temperature in °C for the
+// temperature in °C, relative humidity in percent, wind speed in m/s and the
+// irradiance with the sun at the elevation.  The solar radiation absorbed is
+// that of Dimiceli's method, with the ISO 7726 convection from the globe, and
+// the heat balance is solved by Newton's method rather than linearising the
+// radiation from the globe, which overestimates the temperature without sun.
+func globeTemperature(t, rh, wind float64, irr irradiance, elevation float64) float64 {
+	ta := t + kelvin
+
+	// Emissivity of the atmosphere from the vapour pressure in hPa.
+	ea := rh / 100 * saturationVapourPressure(t) * 10
+	emissivity := 0.575 * math.Pow(ea, 1.0/7)
+
+	// Long wave radiation reaches the globe from the sky above and from the
+	// ground below, which is taken to be at the air temperature.
+	b := (emissivity + 1) / 2 * math.Pow(ta, 4)
+	if irr.GHI > 0 {
+		cosZenith := math.Max(math.Sin(elevation*deg2rad), math.Cos(89.5*deg2rad))
+		direct := irr.DNI * cosZenith / irr.GHI
+		diffuse := irr.DHI / irr.GHI
+
+		b += irr.GHI * (direct/(4*stefanBoltzmann*cosZenith) + 1.2/stefanBoltzmann*diffuse)
+	}
+
+	// Heat lost by forced convection, relative to that radiated.
+	c := 6.3 * math.Pow(math.Max(wind, minGlobeWind), 0.6) / math.Pow(globeDiameter, 0.4) / (globeEmissivity * stefanBoltzmann)
+
+	// Tg⁴ + c·Tg = b + c·Ta
+	tg := ta
+	for i := 0; i < 20; i++ {
+		step := (math.Pow(tg, 4) + c*tg - b - c*ta) / (4*math.Pow(tg, 3) + c)
+		tg -= step
+		if math.Abs(step) < 1e-6 {
+			break
+		}
+	}
+
+	return tg - kelvin
+}
+
+// wbgt returns the outdoor wet-bulb globe temperature in °C, with the natural
+// wet bulb approximated by the psychrometric wet bulb.
+func wbgt(t, rh, wind float64, irr irradiance, elevation float64) float64 {
+	return 0.7*wetBulb(t, rh) + 0.2*globeTemperature(t, rh, wind, irr, elevation) + 0.1*t
+}
+
+// addWBGT adds the wet bulb and wet-bulb globe temperatures at the time dt
+// to conditions, with the solar load estimated from the sun elevation and
+// cloud cover.
+func addWBGT(location Location, dt int, conditions map[string]float64) {
+	t, ok := conditions["temp"]
+	if !ok {
+		return
+	}
+
+	rh, ok := conditions["humidity"]
+	if !ok {
+		return
+	}
+
+	wind, ok := conditions["wind_speed"]
+	if !ok {
+		return
+	}
+
+	clouds, ok := conditions["clouds"]
+	if !ok {
+		clouds = 50
+	}
+
+	at := time.Unix(int64(dt), 0)
+	elevation, _ := sunPosition(at, location.Latitude, location.Longitude)
+	irr := estimateIrradiance(at, location.Latitude, location.Longitude, clouds)
+
+	conditions["wet_bulb"] = wetBulb(t, rh)
+	conditions["wbgt"] = wbgt(t, rh, wind, irr, elevation)
+}
+
+// wbgtCategory returns the number of thresholds the WBGT has reached.
+func (o *OWM) wbgtCategory(wbgt float64) float64 {
+	thresholds := o.cfg.WBGTThresholds
+	if len(thresholds) == 0 {
+		thresholds = defaultWBGTThresholds
+	}
+
+	var category float64
+	for _, threshold := range thresholds {
+		if wbgt >= threshold {
+			category++
+		}
+	}
+
+	return category
+}
+
+// emitWBGT sends the heat stress category of the current conditions and each
+// forecast hour.
+func (o *OWM) emitWBGT(ch chan<- prometheus.Metric, provider string, location Location, weather *Weather) {
+	if c := weather.Current; c != nil {
+		if v, ok := c.Conditions["wbgt"]; ok {
+			ch <- prometheus.MustNewConstMetric(
+				metricWBGTCategoryDesc,
+				prometheus.GaugeValue,
+				o.wbgtCategory(v),
+				location.Name,
+				provider,
+			)
+		}
+	}
+
+	for _, hour := range weather.Hourly {
+		v, ok := hour.Conditions["wbgt"]
+		if !ok {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			metricForecastWBGTCategoryDesc,
+			prometheus.GaugeValue,
+			o.wbgtCategory(v),
+			location.Name,
+			provider,
+			futureHours(hour.Dt),
+		)
+	}
+}
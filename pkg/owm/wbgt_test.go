@@ -0,0 +1,58 @@
+package owm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWetBulb(t *testing.T) {
+	// Stull's example.
+	require.InDelta(t, 13.7, wetBulb(20, 50), 0.05)
+	// Saturated air cools no further.
+	require.InDelta(t, 25, wetBulb(25, 99), 0.3)
+}
+
+func TestGlobeTemperature(t *testing.T) {
+	// Without sun the globe is about the air temperature.
+	require.InDelta(t, 25, globeTemperature(25, 50, 2, irradiance{}, -10), 1.5)
+
+	// Full sun heats the globe well above the air, less so in a breeze.
+	irr := irradiance{GHI: 900, DNI: 850, DHI: 120}
+	calm := globeTemperature(30, 50, 0.5, irr, 60)
+	breezy := globeTemperature(30, 50, 5, irr, 60)
+	require.Greater(t, calm, breezy+5)
+	require.InDelta(t, 38, breezy, 3)
+}
+
+func TestAddWBGT(t *testing.T) {
+	location := Location{Name: "yard", Latitude: 33.4, Longitude: -112}
+	noon := time.Date(2022, 7, 1, 19, 0, 0, 0, time.UTC)
+	midnight := time.Date(2022, 7, 1, 7, 0, 0, 0, time.UTC)
+
+	day := map[string]float64{"temp": 35, "humidity": 40, "wind_speed": 2, "clouds": 0}
+	addWBGT(location, int(noon.Unix()), day)
+
+	night := map[string]float64{"temp": 35, "humidity": 40, "wind_speed": 2, "clouds": 0}
+	addWBGT(location, int(midnight.Unix()), night)
+
+	require.Greater(t, day["wbgt"], night["wbgt"]+1)
+	require.InDelta(t, 32, day["wbgt"], 2)
+	require.Less(t, day["wet_bulb"], 35.0)
+
+	// Wind is needed for the globe temperature.
+	still := map[string]float64{"temp": 35, "humidity": 40}
+	addWBGT(location, int(noon.Unix()), still)
+	require.NotContains(t, still, "wbgt")
+}
+
+func TestWBGTCategory(t *testing.T) {
+	o := newTestOWM(Config{}, nil)
+	require.Equal(t, 0.0, o.wbgtCategory(25))
+	require.Equal(t, 2.0, o.wbgtCategory(29.4))
+	require.Equal(t, 4.0, o.wbgtCategory(35))
+
+	o = newTestOWM(Config{WBGTThresholds: []float64{25, 28}}, nil)
+	require.Equal(t, 1.0, o.wbgtCategory(27))
+}
@@ -0,0 +1,146 @@
+package owm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metricForecastWindowDesc = prometheus.NewDesc(
+	"weather_forecast_window",
+	"Statistics of each forecast condition over the next hours: (min|max|mean|sum)",
+	[]string{"location", "provider", "condition", "window", "stat"},
+	nil,
+)
+
+// windowStats are the forecasts of a condition over a window, weighted by
+// the hours of each that fall inside it.
+type windowStats struct {
+	values  []float64
+	weights []float64
+}
+
+func (s *windowStats) add(v, weight float64) {
+	s.values = append(s.values, v)
+	s.weights = append(s.weights, weight)
+}
+
+// stats returns the min, max, mean and sum of the forecasts.  The sum is of
+// each value over the hours it holds for, so hourly amounts add up to the
+// total over the window.  Bearings have a circular mean and no sum, with min
+// and max the ends of the smallest arc containing them.
+func (s *windowStats) stats(circular bool) map[string]float64 {
+	var hours float64
+	for _, w := range s.weights {
+		hours += w
+	}
+
+	if circular {
+		min, max, _ := bearingArc(s.values)
+
+		return map[string]float64{
+			"min":  min,
+			"max":  max,
+			"mean": circularMean(s.values, s.weights),
+		}
+	}
+
+	min, max, sum := s.values[0], s.values[0], 0.0
+	for i, v := range s.values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+		sum += v * s.weights[i]
+	}
+
+	return map[string]float64{
+		"min":  min,
+		"max":  max,
+		"mean": sum / hours,
+		"sum":  sum,
+	}
+}
+
+// forecastWindow returns the forecasts of each condition over the window from
+// now, or false when the forecasts do not reach its end.  Each forecast holds
+// until the next, and is weighted by the part of it inside the window.
+func forecastWindow(now time.Time, hours []Forecast, window time.Duration) (map[string]*windowStats, bool) {
+	sorted := make([]Forecast, len(hours))
+	copy(sorted, hours)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Dt < sorted[j].Dt })
+
+	end := now.Add(window)
+	step := time.Hour
+
+	stats := make(map[string]*windowStats)
+	var covered bool
+	for i, h := range sorted {
+		start := time.Unix(int64(h.Dt), 0)
+		if i+1 < len(sorted) {
+			step = time.Unix(int64(sorted[i+1].Dt), 0).Sub(start)
+		}
+
+		// Clip the period to the window.
+		from, to := start, start.Add(step)
+		if from.Before(now) {
+			from = now
+		}
+		if !to.Before(end) {
+			to = end
+			covered = true
+		}
+		if !to.After(from) {
+			if covered {
+				break
+			}
+			continue
+		}
+
+		for condition, v := range h.Conditions {
+			s, ok := stats[condition]
+			if !ok {
+				s = &windowStats{}
+				stats[condition] = s
+			}
+			s.add(v, to.Sub(from).Hours())
+		}
+	}
+
+	return stats, covered
+}
+
+// windowLabel returns the window label value, eg: 24h.
+func windowLabel(window time.Duration) string {
+	return fmt.Sprintf("%dh", int(window.Hours()))
+}
+
+// emitForecastWindows sends the statistics of each condition over the
+// configured windows of the hourly forecast from a provider.
+func (o *OWM) emitForecastWindows(ch chan<- prometheus.Metric, provider string, location Location, hours []Forecast) {
+	now := time.Now()
+
+	for _, window := range o.cfg.ForecastWindows {
+		stats, ok := forecastWindow(now, hours, window)
+		if !ok {
+			continue
+		}
+
+		label := windowLabel(window)
+		for condition, s := range stats {
+			for stat, value := range s.stats(circularConditions[condition]) {
+				ch <- prometheus.MustNewConstMetric(
+					metricForecastWindowDesc,
+					prometheus.GaugeValue,
+					value,
+					location.Name,
+					provider,
+					condition,
+					label,
+					stat,
+				)
+			}
+		}
+	}
+}
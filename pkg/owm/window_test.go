@@ -0,0 +1,93 @@
+package owm
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecastWindow(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 20, 0, 0, time.UTC)
+
+	var hours []Forecast
+	for i := 0; i < 12; i++ {
+		hours = append(hours, Forecast{
+			Dt:         int(now.Truncate(time.Hour).Add(time.Duration(i) * time.Hour).Unix()),
+			Conditions: map[string]float64{"temp": float64(10 + i), "rain_1h": 0.5},
+		})
+	}
+
+	// The hour in effect now counts for the 40 minutes left of it, and the
+	// hour crossing the end of the window for the 20 minutes inside it.
+	stats, ok := forecastWindow(now, hours, 6*time.Hour)
+	require.True(t, ok)
+
+	temp := stats["temp"].stats(false)
+	require.Equal(t, 10.0, temp["min"])
+	require.Equal(t, 16.0, temp["max"])
+	require.InDelta(t, (10*2.0/3+11+12+13+14+15+16.0/3)/6, temp["mean"], 1e-9)
+	require.InDelta(t, 3, stats["rain_1h"].stats(false)["sum"], 1e-9)
+
+	// The forecast does not reach the end of the window.
+	_, ok = forecastWindow(now, hours, 12*time.Hour)
+	require.False(t, ok)
+
+	// Forecasts that have passed are left out.
+	stats, ok = forecastWindow(now.Add(2*time.Hour), hours, 6*time.Hour)
+	require.True(t, ok)
+	require.Equal(t, 12.0, stats["temp"].stats(false)["min"])
+}
+
+func TestForecastWindowBearing(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var hours []Forecast
+	for i, deg := range []float64{350, 10, 350, 10} {
+		hours = append(hours, Forecast{
+			Dt:         int(now.Add(time.Duration(i) * time.Hour).Unix()),
+			Conditions: map[string]float64{"wind_degree": deg},
+		})
+	}
+
+	stats, ok := forecastWindow(now, hours, 4*time.Hour)
+	require.True(t, ok)
+
+	// Either side of north averages to north, not south.
+	wind := stats["wind_degree"].stats(true)
+	require.InDelta(t, 0, math.Mod(wind["mean"]+180, 360)-180, 1e-9)
+	require.Equal(t, 350.0, wind["min"])
+	require.Equal(t, 10.0, wind["max"])
+	require.NotContains(t, wind, "sum")
+}
+
+func TestEmitForecastWindows(t *testing.T) {
+	o := newTestOWM(Config{ForecastWindows: []time.Duration{3 * time.Hour, 48 * time.Hour}}, nil)
+	now := time.Now().Truncate(time.Hour)
+
+	var hours []Forecast
+	for i := 0; i < 6; i++ {
+		hours = append(hours, Forecast{
+			Dt:         int(now.Add(time.Duration(i) * time.Hour).Unix()),
+			Conditions: map[string]float64{"temp": float64(20 - i)},
+		})
+	}
+
+	metrics := collectMetrics(t, func(ch chan<- prometheus.Metric) {
+		o.emitForecastWindows(ch, ProviderOpenWeatherMap, Location{Name: "home"}, hours)
+	})
+
+	stats := map[string]float64{}
+	for _, m := range metrics {
+		// Only the 3 hour window is covered.
+		require.Equal(t, "3h", m.label("window"))
+		require.Equal(t, "temp", m.label("condition"))
+		require.Equal(t, ProviderOpenWeatherMap, m.label("provider"))
+		stats[m.label("stat")] = m.GetGauge().GetValue()
+	}
+
+	require.Len(t, stats, 4)
+	require.Equal(t, 20.0, stats["max"])
+}